@@ -0,0 +1,648 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: api/users/v1/users.proto
+
+package usersv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	UserService_CreateUser_FullMethodName       = "/users.v1.UserService/CreateUser"
+	UserService_GetUser_FullMethodName          = "/users.v1.UserService/GetUser"
+	UserService_ListUsers_FullMethodName        = "/users.v1.UserService/ListUsers"
+	UserService_DeleteUser_FullMethodName       = "/users.v1.UserService/DeleteUser"
+	UserService_RestoreUser_FullMethodName      = "/users.v1.UserService/RestoreUser"
+	UserService_CreateUserFile_FullMethodName   = "/users.v1.UserService/CreateUserFile"
+	UserService_UploadUserFile_FullMethodName   = "/users.v1.UserService/UploadUserFile"
+	UserService_GetUserFile_FullMethodName      = "/users.v1.UserService/GetUserFile"
+	UserService_ListUserFiles_FullMethodName    = "/users.v1.UserService/ListUserFiles"
+	UserService_UpdateUserFile_FullMethodName   = "/users.v1.UserService/UpdateUserFile"
+	UserService_DeleteUserFile_FullMethodName   = "/users.v1.UserService/DeleteUserFile"
+	UserService_RestoreUserFile_FullMethodName  = "/users.v1.UserService/RestoreUserFile"
+	UserService_DownloadUserFile_FullMethodName = "/users.v1.UserService/DownloadUserFile"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	// CreateUser creates a new user.
+	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*User, error)
+	// GetUser retrieves a user by id.
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	// ListUsers retrieves all users with pagination.
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	// DeleteUser soft deletes a user by id.
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RestoreUser restores a soft deleted user by id.
+	RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*User, error)
+	// CreateUserFile creates a new user file, fetched from the file serving service
+	// or imported from the requested url.
+	CreateUserFile(ctx context.Context, in *CreateUserFileRequest, opts ...grpc.CallOption) (*UserFile, error)
+	// UploadUserFile creates a new user file with content sent by the client. The
+	// first message carries the details of the file, the next ones its content.
+	UploadUserFile(ctx context.Context, opts ...grpc.CallOption) (UserService_UploadUserFileClient, error)
+	// GetUserFile retrieves a user file by id.
+	GetUserFile(ctx context.Context, in *GetUserFileRequest, opts ...grpc.CallOption) (*UserFile, error)
+	// ListUserFiles retrieves the user files matching the filter with pagination.
+	ListUserFiles(ctx context.Context, in *ListUserFilesRequest, opts ...grpc.CallOption) (*ListUserFilesResponse, error)
+	// UpdateUserFile changes the filename, description, tags or expiry of a user file.
+	UpdateUserFile(ctx context.Context, in *UpdateUserFileRequest, opts ...grpc.CallOption) (*UserFile, error)
+	// DeleteUserFile soft deletes a user file by id.
+	DeleteUserFile(ctx context.Context, in *DeleteUserFileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RestoreUserFile restores a soft deleted user file by id.
+	RestoreUserFile(ctx context.Context, in *RestoreUserFileRequest, opts ...grpc.CallOption) (*UserFile, error)
+	// DownloadUserFile downloads the content of a user file. The first message
+	// carries the details of the content, the next ones the content itself.
+	DownloadUserFile(ctx context.Context, in *DownloadUserFileRequest, opts ...grpc.CallOption) (UserService_DownloadUserFileClient, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_CreateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_GetUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, UserService_ListUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, UserService_DeleteUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_RestoreUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) CreateUserFile(ctx context.Context, in *CreateUserFileRequest, opts ...grpc.CallOption) (*UserFile, error) {
+	out := new(UserFile)
+	err := c.cc.Invoke(ctx, UserService_CreateUserFile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UploadUserFile(ctx context.Context, opts ...grpc.CallOption) (UserService_UploadUserFileClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[0], UserService_UploadUserFile_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userServiceUploadUserFileClient{stream}
+	return x, nil
+}
+
+type UserService_UploadUserFileClient interface {
+	Send(*UploadUserFileRequest) error
+	CloseAndRecv() (*UserFile, error)
+	grpc.ClientStream
+}
+
+type userServiceUploadUserFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *userServiceUploadUserFileClient) Send(m *UploadUserFileRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *userServiceUploadUserFileClient) CloseAndRecv() (*UserFile, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UserFile)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userServiceClient) GetUserFile(ctx context.Context, in *GetUserFileRequest, opts ...grpc.CallOption) (*UserFile, error) {
+	out := new(UserFile)
+	err := c.cc.Invoke(ctx, UserService_GetUserFile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListUserFiles(ctx context.Context, in *ListUserFilesRequest, opts ...grpc.CallOption) (*ListUserFilesResponse, error) {
+	out := new(ListUserFilesResponse)
+	err := c.cc.Invoke(ctx, UserService_ListUserFiles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UpdateUserFile(ctx context.Context, in *UpdateUserFileRequest, opts ...grpc.CallOption) (*UserFile, error) {
+	out := new(UserFile)
+	err := c.cc.Invoke(ctx, UserService_UpdateUserFile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) DeleteUserFile(ctx context.Context, in *DeleteUserFileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, UserService_DeleteUserFile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RestoreUserFile(ctx context.Context, in *RestoreUserFileRequest, opts ...grpc.CallOption) (*UserFile, error) {
+	out := new(UserFile)
+	err := c.cc.Invoke(ctx, UserService_RestoreUserFile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) DownloadUserFile(ctx context.Context, in *DownloadUserFileRequest, opts ...grpc.CallOption) (UserService_DownloadUserFileClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[1], UserService_DownloadUserFile_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userServiceDownloadUserFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserService_DownloadUserFileClient interface {
+	Recv() (*DownloadUserFileResponse, error)
+	grpc.ClientStream
+}
+
+type userServiceDownloadUserFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *userServiceDownloadUserFileClient) Recv() (*DownloadUserFileResponse, error) {
+	m := new(DownloadUserFileResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility
+type UserServiceServer interface {
+	// CreateUser creates a new user.
+	CreateUser(context.Context, *CreateUserRequest) (*User, error)
+	// GetUser retrieves a user by id.
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	// ListUsers retrieves all users with pagination.
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	// DeleteUser soft deletes a user by id.
+	DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error)
+	// RestoreUser restores a soft deleted user by id.
+	RestoreUser(context.Context, *RestoreUserRequest) (*User, error)
+	// CreateUserFile creates a new user file, fetched from the file serving service
+	// or imported from the requested url.
+	CreateUserFile(context.Context, *CreateUserFileRequest) (*UserFile, error)
+	// UploadUserFile creates a new user file with content sent by the client. The
+	// first message carries the details of the file, the next ones its content.
+	UploadUserFile(UserService_UploadUserFileServer) error
+	// GetUserFile retrieves a user file by id.
+	GetUserFile(context.Context, *GetUserFileRequest) (*UserFile, error)
+	// ListUserFiles retrieves the user files matching the filter with pagination.
+	ListUserFiles(context.Context, *ListUserFilesRequest) (*ListUserFilesResponse, error)
+	// UpdateUserFile changes the filename, description, tags or expiry of a user file.
+	UpdateUserFile(context.Context, *UpdateUserFileRequest) (*UserFile, error)
+	// DeleteUserFile soft deletes a user file by id.
+	DeleteUserFile(context.Context, *DeleteUserFileRequest) (*emptypb.Empty, error)
+	// RestoreUserFile restores a soft deleted user file by id.
+	RestoreUserFile(context.Context, *RestoreUserFileRequest) (*UserFile, error)
+	// DownloadUserFile downloads the content of a user file. The first message
+	// carries the details of the content, the next ones the content itself.
+	DownloadUserFile(*DownloadUserFileRequest, UserService_DownloadUserFileServer) error
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUserServiceServer struct {
+}
+
+func (UnimplementedUserServiceServer) CreateUser(context.Context, *CreateUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserServiceServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedUserServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedUserServiceServer) RestoreUser(context.Context, *RestoreUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUser not implemented")
+}
+func (UnimplementedUserServiceServer) CreateUserFile(context.Context, *CreateUserFileRequest) (*UserFile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) UploadUserFile(UserService_UploadUserFileServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) GetUserFile(context.Context, *GetUserFileRequest) (*UserFile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) ListUserFiles(context.Context, *ListUserFilesRequest) (*ListUserFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserFiles not implemented")
+}
+func (UnimplementedUserServiceServer) UpdateUserFile(context.Context, *UpdateUserFileRequest) (*UserFile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) DeleteUserFile(context.Context, *DeleteUserFileRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) RestoreUserFile(context.Context, *RestoreUserFileRequest) (*UserFile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) DownloadUserFile(*DownloadUserFileRequest, UserService_DownloadUserFileServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadUserFile not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).CreateUser(ctx, req.(*CreateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).DeleteUser(ctx, req.(*DeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RestoreUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RestoreUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RestoreUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RestoreUser(ctx, req.(*RestoreUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_CreateUserFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).CreateUserFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_CreateUserFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).CreateUserFile(ctx, req.(*CreateUserFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UploadUserFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UserServiceServer).UploadUserFile(&userServiceUploadUserFileServer{stream})
+}
+
+type UserService_UploadUserFileServer interface {
+	SendAndClose(*UserFile) error
+	Recv() (*UploadUserFileRequest, error)
+	grpc.ServerStream
+}
+
+type userServiceUploadUserFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *userServiceUploadUserFileServer) SendAndClose(m *UserFile) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *userServiceUploadUserFileServer) Recv() (*UploadUserFileRequest, error) {
+	m := new(UploadUserFileRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _UserService_GetUserFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUserFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUserFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUserFile(ctx, req.(*GetUserFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListUserFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUserFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListUserFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUserFiles(ctx, req.(*ListUserFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UpdateUserFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateUserFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UpdateUserFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateUserFile(ctx, req.(*UpdateUserFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_DeleteUserFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).DeleteUserFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_DeleteUserFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).DeleteUserFile(ctx, req.(*DeleteUserFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RestoreUserFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RestoreUserFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RestoreUserFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RestoreUserFile(ctx, req.(*RestoreUserFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_DownloadUserFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadUserFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).DownloadUserFile(m, &userServiceDownloadUserFileServer{stream})
+}
+
+type UserService_DownloadUserFileServer interface {
+	Send(*DownloadUserFileResponse) error
+	grpc.ServerStream
+}
+
+type userServiceDownloadUserFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *userServiceDownloadUserFileServer) Send(m *DownloadUserFileResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUser",
+			Handler:    _UserService_CreateUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _UserService_GetUser_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _UserService_ListUsers_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _UserService_DeleteUser_Handler,
+		},
+		{
+			MethodName: "RestoreUser",
+			Handler:    _UserService_RestoreUser_Handler,
+		},
+		{
+			MethodName: "CreateUserFile",
+			Handler:    _UserService_CreateUserFile_Handler,
+		},
+		{
+			MethodName: "GetUserFile",
+			Handler:    _UserService_GetUserFile_Handler,
+		},
+		{
+			MethodName: "ListUserFiles",
+			Handler:    _UserService_ListUserFiles_Handler,
+		},
+		{
+			MethodName: "UpdateUserFile",
+			Handler:    _UserService_UpdateUserFile_Handler,
+		},
+		{
+			MethodName: "DeleteUserFile",
+			Handler:    _UserService_DeleteUserFile_Handler,
+		},
+		{
+			MethodName: "RestoreUserFile",
+			Handler:    _UserService_RestoreUserFile_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadUserFile",
+			Handler:       _UserService_UploadUserFile_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadUserFile",
+			Handler:       _UserService_DownloadUserFile_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/users/v1/users.proto",
+}
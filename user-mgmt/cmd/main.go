@@ -6,10 +6,20 @@ import (
 	"github.com/pedromspeixoto/users-api/internal/data"
 	"github.com/pedromspeixoto/users-api/internal/data/models"
 	"github.com/pedromspeixoto/users-api/internal/domain"
+	"github.com/pedromspeixoto/users-api/internal/domain/events"
+	"github.com/pedromspeixoto/users-api/internal/domain/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/grpc"
 	"github.com/pedromspeixoto/users-api/internal/http"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+	pkgevents "github.com/pedromspeixoto/users-api/internal/pkg/events"
 	"github.com/pedromspeixoto/users-api/internal/pkg/files"
 	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/mailer"
+	"github.com/pedromspeixoto/users-api/internal/pkg/scanner"
+	"github.com/pedromspeixoto/users-api/internal/pkg/tokens"
 	"github.com/pedromspeixoto/users-api/internal/pkg/validator"
 	"go.uber.org/fx"
 )
@@ -38,8 +48,19 @@ func main() {
 		domain.ProvideDomains(),
 		handlers.ProvideHandlers(),
 		files.ProvideFileServingClient(),
+		files.ProvideURLImporter(),
+		mailer.ProvideMailer(),
+		scanner.ProvideScanner(),
+		encryption.ProvideKeyProvider(),
+		tokens.ProvideTokenSigner(),
+		pkgevents.ProvideSinks(),
 		// Invoke
 		http.InvokeServer(),
+		grpc.InvokeServer(),
+		events.InvokeRelay(),
+		webhooks.InvokeDispatcher(),
+		jobs.InvokeWorker(),
+		users.InvokeExpirySweeper(),
 	)
 
 	app.Run()
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"github.com/pedromspeixoto/users-api/internal/data/models"
+	"github.com/pedromspeixoto/users-api/internal/domain/storage"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+// rotate-keys wraps the data keys of every stored file and webhook secret with the
+// active master key, so retired master keys can be removed from the keyfile once it completes. It is
+// safe to run while the api is serving requests, and to run again when interrupted.
+func main() {
+	var cfgFilePath string
+	flag.StringVar(
+		&cfgFilePath,
+		"config",
+		"",
+		"Path to config file. If not provided, config will be parsed from the environment.",
+	)
+	flag.Parse()
+
+	app := fx.New(
+		fx.NopLogger,
+		// Provide
+		config.ProvideConfig(cfgFilePath),
+		logger.ProvideLogger(),
+		data.ProvideData(),
+		models.ProvideModels(),
+		encryption.ProvideKeyProvider(),
+		fx.Provide(storage.NewFileStorage),
+		fx.Provide(webhooks.NewWebhookService),
+		// Invoke
+		fx.Invoke(rotateKeys),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatalf("key rotation failed: %v", err)
+	}
+}
+
+func rotateKeys(fileStorage storage.FileStorage, webhookService webhooks.WebhookService, loggingClient *logger.LoggingClient) error {
+	log := loggingClient.GetLogger()
+
+	result, err := fileStorage.RotateKeys(context.Background())
+	if result != nil {
+		log.Infof("rotated to key %s: %d data keys re-wrapped, %d blobs encrypted, %d thumbnails dropped",
+			result.KeyId, result.Rewrapped, result.Encrypted, result.ThumbnailsDropped)
+	}
+	if err != nil {
+		return err
+	}
+
+	rotated, err := webhookService.RotateSecrets(context.Background())
+	log.Infof("%d webhook secrets encrypted again", rotated)
+	return err
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -14,6 +15,13 @@ type Config struct {
 	Environment  string `envconfig:"ENV" default:"development"`
 	Port         string `envconfig:"APP_PORT" default:"8080"`
 	AllowedHosts string `envconfig:"ALLOWED_HOSTS" default:"*"`
+	// TrustedProxies are the ips or CIDR ranges whose X-Forwarded-For and X-Real-IP
+	// headers are honored, none by default
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES" required:"false"`
+
+	// gRPC
+	GrpcPort                string        `envconfig:"GRPC_PORT" required:"false" default:"9090"`
+	GrpcHealthCheckInterval time.Duration `envconfig:"GRPC_HEALTH_CHECK_INTERVAL" required:"false" default:"10s"`
 
 	// Logging
 	LoggerType  string `envconfig:"LOGGER_TYPE" required:"false" default:"zap"`
@@ -27,7 +35,108 @@ type Config struct {
 	MySQLDBName   string `envconfig:"MYSQL_DB_NAME" required:"false" default:"dev_users"`
 
 	// File Serving URL
-	FileServingUrl string `envconfig:"FILE_SERVING_URL" required:"false" default:"http://localhost:3000"`
+	FileServingUrl              string        `envconfig:"FILE_SERVING_URL" required:"false" default:"http://localhost:3000"`
+	FileServingTimeout          time.Duration `envconfig:"FILE_SERVING_TIMEOUT" required:"false" default:"10s"`
+	FileServingMaxRetries       int           `envconfig:"FILE_SERVING_MAX_RETRIES" required:"false" default:"3"`
+	FileServingRetryBackoff     time.Duration `envconfig:"FILE_SERVING_RETRY_BACKOFF" required:"false" default:"200ms"`
+	FileServingBreakerThreshold int           `envconfig:"FILE_SERVING_BREAKER_THRESHOLD" required:"false" default:"5"`
+	FileServingBreakerCooldown  time.Duration `envconfig:"FILE_SERVING_BREAKER_COOLDOWN" required:"false" default:"30s"`
+
+	// File limits and per-user quotas, zero disables a limit
+	FileMaxSize      int64    `envconfig:"FILE_MAX_SIZE" required:"false" default:"10485760"`
+	FileAllowedTypes []string `envconfig:"FILE_ALLOWED_TYPES" required:"false" default:"image/png,image/jpeg,image/gif,application/pdf,application/zip"`
+	UserQuotaBytes   int64    `envconfig:"USER_QUOTA_BYTES" required:"false" default:"104857600"`
+	UserQuotaFiles   int64    `envconfig:"USER_QUOTA_FILES" required:"false" default:"100"`
+	FileMaxVersions  int      `envconfig:"FILE_MAX_VERSIONS" required:"false" default:"10"`
+
+	// File archives are streamed under their own deadline, longer than other requests
+	FileArchiveTimeout time.Duration `envconfig:"FILE_ARCHIVE_TIMEOUT" required:"false" default:"30m"`
+
+	// File expiry, retention is the default lifetime per mime type (e.g. application/zip:720h)
+	FileRetention            map[string]time.Duration `envconfig:"FILE_RETENTION" required:"false"`
+	FileExpirySweepInterval  time.Duration            `envconfig:"FILE_EXPIRY_SWEEP_INTERVAL" required:"false" default:"1m"`
+	FileExpirySweepBatchSize int                      `envconfig:"FILE_EXPIRY_SWEEP_BATCH_SIZE" required:"false" default:"100"`
+
+	// Malware scanning
+	ScannerType     string        `envconfig:"SCANNER_TYPE" required:"false" default:"clamd"`
+	ScannerTimeout  time.Duration `envconfig:"SCANNER_TIMEOUT" required:"false" default:"30s"`
+	ScannerFailOpen bool          `envconfig:"SCANNER_FAIL_OPEN" required:"false" default:"false"`
+	ClamdAddress    string        `envconfig:"CLAMD_ADDRESS" required:"false" default:"localhost:3310"`
+
+	// Encryption at rest, see the encryption package for the keyfile format
+	EncryptionKeyProvider string `envconfig:"ENCRYPTION_KEY_PROVIDER" required:"false" default:"local"`
+	EncryptionKeyFile     string `envconfig:"ENCRYPTION_KEY_FILE" required:"false" default:"keys/master.keys"`
+	EncryptionKeyId       string `envconfig:"ENCRYPTION_KEY_ID" required:"false"`
+	// EncryptionGenerateKey creates the keyfile when it is missing, for development only:
+	// every instance would otherwise encrypt with a master key of its own
+	EncryptionGenerateKey bool `envconfig:"ENCRYPTION_GENERATE_KEY" required:"false" default:"false"`
+
+	// Full-text search
+	SearchMaxTextSize int `envconfig:"SEARCH_MAX_TEXT_SIZE" required:"false" default:"1048576"`
+
+	// Thumbnails
+	ThumbnailSizes       []int `envconfig:"THUMBNAIL_SIZES" required:"false" default:"64,128,256"`
+	ThumbnailDefaultSize int   `envconfig:"THUMBNAIL_DEFAULT_SIZE" required:"false" default:"128"`
+
+	// File imports from caller supplied urls, only from FileImportAllowedHosts: no
+	// file can be imported when it is empty
+	FileImportAllowedSchemes []string      `envconfig:"FILE_IMPORT_ALLOWED_SCHEMES" required:"false" default:"https"`
+	FileImportAllowedHosts   []string      `envconfig:"FILE_IMPORT_ALLOWED_HOSTS" required:"false"`
+	FileImportMaxSize        int64         `envconfig:"FILE_IMPORT_MAX_SIZE" required:"false" default:"10485760"`
+	FileImportMaxRedirects   int           `envconfig:"FILE_IMPORT_MAX_REDIRECTS" required:"false" default:"3"`
+	FileImportTimeout        time.Duration `envconfig:"FILE_IMPORT_TIMEOUT" required:"false" default:"30s"`
+
+	// File shares, the secret signs share links and has no default
+	ShareTokenSecret string        `envconfig:"SHARE_TOKEN_SECRET" required:"true"`
+	ShareUrl         string        `envconfig:"SHARE_URL" required:"false" default:"http://localhost:8080/v1/shared"`
+	ShareDefaultTTL  time.Duration `envconfig:"SHARE_DEFAULT_TTL" required:"false" default:"24h"`
+	ShareMaxTTL      time.Duration `envconfig:"SHARE_MAX_TTL" required:"false" default:"168h"`
+
+	// Idempotency
+	IdempotencyKeyTTL time.Duration `envconfig:"IDEMPOTENCY_KEY_TTL" required:"false" default:"24h"`
+
+	// Email verification, the secret signs verification tokens and has no default
+	VerificationTokenSecret string        `envconfig:"VERIFICATION_TOKEN_SECRET" required:"true"`
+	VerificationTokenTTL    time.Duration `envconfig:"VERIFICATION_TOKEN_TTL" required:"false" default:"24h"`
+	VerificationUrl         string        `envconfig:"VERIFICATION_URL" required:"false" default:"http://localhost:8080/v1/users"`
+
+	// Mailer
+	MailerType     string        `envconfig:"MAILER_TYPE" required:"false" default:"log"`
+	MailerFrom     string        `envconfig:"MAILER_FROM" required:"false" default:"no-reply@users-api.local"`
+	MailerFilePath string        `envconfig:"MAILER_FILE_PATH" required:"false" default:"mails"`
+	MailerTimeout  time.Duration `envconfig:"MAILER_TIMEOUT" required:"false" default:"5s"`
+	SMTPHost       string        `envconfig:"SMTP_HOST" required:"false" default:"localhost"`
+	SMTPPort       string        `envconfig:"SMTP_PORT" required:"false" default:"25"`
+	SMTPUser       string        `envconfig:"SMTP_USER" required:"false"`
+	SMTPPassword   string        `envconfig:"SMTP_PASSWORD" required:"false"`
+
+	// Outbox
+	OutboxSinks          []string      `envconfig:"OUTBOX_SINKS" required:"false" default:"stdout"`
+	OutboxPollInterval   time.Duration `envconfig:"OUTBOX_POLL_INTERVAL" required:"false" default:"1s"`
+	OutboxBatchSize      int           `envconfig:"OUTBOX_BATCH_SIZE" required:"false" default:"100"`
+	OutboxMaxBackoff     time.Duration `envconfig:"OUTBOX_MAX_BACKOFF" required:"false" default:"5m"`
+	OutboxMaxAttempts    int           `envconfig:"OUTBOX_MAX_ATTEMPTS" required:"false" default:"10"`
+	OutboxClaimLease     time.Duration `envconfig:"OUTBOX_CLAIM_LEASE" required:"false" default:"1m"`
+	OutboxWebhookUrl     string        `envconfig:"OUTBOX_WEBHOOK_URL" required:"false"`
+	OutboxWebhookTimeout time.Duration `envconfig:"OUTBOX_WEBHOOK_TIMEOUT" required:"false" default:"10s"`
+	NatsUrl              string        `envconfig:"NATS_URL" required:"false" default:"nats://localhost:4222"`
+	NatsSubjectPrefix    string        `envconfig:"NATS_SUBJECT_PREFIX" required:"false" default:"users-api"`
+	KafkaBrokers         []string      `envconfig:"KAFKA_BROKERS" required:"false" default:"localhost:9092"`
+	KafkaTopic           string        `envconfig:"KAFKA_TOPIC" required:"false" default:"users-api.events"`
+
+	// Webhooks
+	WebhookPollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" required:"false" default:"1s"`
+	WebhookBatchSize    int           `envconfig:"WEBHOOK_BATCH_SIZE" required:"false" default:"100"`
+	WebhookTimeout      time.Duration `envconfig:"WEBHOOK_TIMEOUT" required:"false" default:"10s"`
+	WebhookMaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" required:"false" default:"8"`
+	WebhookMaxBackoff   time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" required:"false" default:"1h"`
+	WebhookClaimLease   time.Duration `envconfig:"WEBHOOK_CLAIM_LEASE" required:"false" default:"1m"`
+
+	// Jobs
+	JobWorkers       int           `envconfig:"JOB_WORKERS" required:"false" default:"4"`
+	JobPollInterval  time.Duration `envconfig:"JOB_POLL_INTERVAL" required:"false" default:"1s"`
+	JobLeaseDuration time.Duration `envconfig:"JOB_LEASE_DURATION" required:"false" default:"30s"`
+	JobMaxAttempts   int           `envconfig:"JOB_MAX_ATTEMPTS" required:"false" default:"3"`
 }
 
 func ProvideConfig(cfgFile string) fx.Option {
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+// AuditEvent is an immutable record of a mutating operation.
+type AuditEvent struct {
+	ID             uint `gorm:"primarykey"`
+	EventId        string
+	Actor          string
+	Action         string
+	TargetType     string
+	TargetId       string
+	RequestId      string
+	ClientIp       string
+	BeforeSnapshot *string
+	AfterSnapshot  *string
+	CreatedAt      time.Time
+}
+
+// AuditEventFilter restricts the audit events returned by List.
+type AuditEventFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetId   string
+	RequestId  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditEventRepository is a repository for dealing with audit events. Events
+// can only be appended and read, never updated or deleted.
+type AuditEventRepository interface {
+	// List lists audit events matching the filter from the database with pagination.
+	List(filter *AuditEventFilter, limit, page int) ([]AuditEvent, *data.Pagination, error)
+	// Create appends an audit event to the database.
+	Create(event *AuditEvent) error
+}
+
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventRepository(db *gorm.DB) AuditEventRepository {
+	return &auditEventRepository{
+		db: db,
+	}
+}
+
+func (a auditEventRepository) List(filter *AuditEventFilter, limit, page int) ([]AuditEvent, *data.Pagination, error) {
+	var events []AuditEvent
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+		Sort:  "created_at desc, id desc",
+	}
+
+	query := a.filter(filter)
+	result := query.Scopes(pagination.Paginate()).Find(&events)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	result = a.filter(filter).Model(&AuditEvent{}).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return events, pagination, nil
+}
+
+func (a auditEventRepository) filter(filter *AuditEventFilter) *gorm.DB {
+	query := a.db
+	if filter == nil {
+		return query
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		query = query.Where("target_id = ?", filter.TargetId)
+	}
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	return query
+}
+
+func (a auditEventRepository) Create(event *AuditEvent) error {
+	result := a.db.Create(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique constraint violations.
+const mysqlDuplicateEntry = 1062
+
+var ErrKeyExists = errors.New("idempotency key already exists")
+
+const (
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+)
+
+// IdempotencyKey stores the fingerprint of a request sent with an Idempotency-Key
+// header and, once completed, its response so it can be replayed.
+type IdempotencyKey struct {
+	ID                  uint `gorm:"primarykey"`
+	Actor               string
+	IdempotencyKey      string
+	Fingerprint         string
+	Status              string
+	ResponseStatus      *int
+	ResponseContentType *string
+	ResponseBody        []byte
+	ExpiresAt           time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// IdempotencyKeyRepository is a repository for dealing with idempotency keys.
+type IdempotencyKeyRepository interface {
+	// Get gets an idempotency key of an actor from the database.
+	Get(actor, key string) (*IdempotencyKey, error)
+	// Create creates an idempotency key in the database, failing with ErrKeyExists
+	// if the actor already used the key.
+	Create(key *IdempotencyKey) error
+	// Update updates an idempotency key in the database.
+	Update(key *IdempotencyKey) error
+	// Delete deletes an idempotency key from the database.
+	Delete(key *IdempotencyKey) error
+	// DeleteExpired deletes all expired idempotency keys from the database.
+	DeleteExpired() error
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{
+		db: db,
+	}
+}
+
+func (i idempotencyKeyRepository) Get(actor, key string) (*IdempotencyKey, error) {
+	idempotencyKey := IdempotencyKey{}
+	result := i.db.Where("actor = ? AND idempotency_key = ?", actor, key).Find(&idempotencyKey)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &idempotencyKey, nil
+}
+
+func (i idempotencyKeyRepository) Create(key *IdempotencyKey) error {
+	result := i.db.Create(key)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(result.Error, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrKeyExists
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (i idempotencyKeyRepository) Update(key *IdempotencyKey) error {
+	result := i.db.Save(key)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (i idempotencyKeyRepository) Delete(key *IdempotencyKey) error {
+	result := i.db.Delete(key)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (i idempotencyKeyRepository) DeleteExpired() error {
+	result := i.db.Where("expires_at < ?", time.Now()).Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package jobs
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TypeFileIngest = "file.ingest"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a unit of background work. Running jobs hold a lease that their worker
+// renews; a job whose lease expired is claimed again by another worker.
+type Job struct {
+	ID        uint `gorm:"primarykey"`
+	JobId     string
+	Type      string
+	UserId    string
+	SourceUrl *string
+	// Payload is the json encoded request the job was queued with.
+	Payload         *string
+	Actor           string
+	RequestId       *string
+	Status          string
+	Progress        int
+	Attempts        int
+	ResultId        *string
+	Error           *string
+	Code            *string
+	ErrorCode       *int
+	CancelRequested bool
+	LeaseOwner      *string
+	LeaseExpiresAt  *time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// JobRepository is a repository for dealing with jobs.
+type JobRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) JobRepository
+	// GetByUUID gets a job from the database by uuid.
+	GetByUUID(uuid string) (*Job, error)
+	// Create creates a job in the database.
+	Create(job *Job) error
+	// Claim leases the oldest queued job, or running job whose lease expired, to owner.
+	// Jobs whose cancellation was requested are cancelled instead of claimed. It
+	// returns nil when there is nothing to claim.
+	Claim(owner string, lease time.Duration) (*Job, error)
+	// Heartbeat stores the progress of a job and renews its lease. It returns whether
+	// cancellation of the job was requested.
+	Heartbeat(job *Job, lease time.Duration) (bool, error)
+	// Release hands a running job back to the queue.
+	Release(job *Job) error
+	// StoreResult stores the result of a running job while its lease is held. It
+	// returns gorm.ErrRecordNotFound when the lease was lost.
+	StoreResult(job *Job, resultId string) error
+	// Finish stores the final status, result and error of a job.
+	Finish(job *Job) error
+	// Cancel cancels a queued job or requests the cancellation of a running job.
+	Cancel(job *Job) error
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{
+		db: db,
+	}
+}
+
+func (j jobRepository) WithTx(tx *gorm.DB) JobRepository {
+	return &jobRepository{
+		db: tx,
+	}
+}
+
+func (j jobRepository) GetByUUID(uuid string) (*Job, error) {
+	job := Job{}
+	result := j.db.Where("job_id = ?", uuid).Find(&job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (j jobRepository) Create(job *Job) error {
+	result := j.db.Create(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (j jobRepository) Claim(owner string, lease time.Duration) (*Job, error) {
+	claimable := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? OR (status = ? AND lease_expires_at <= ?)", StatusQueued, StatusRunning, time.Now())
+	}
+
+	// a job whose worker was lost after its cancellation was requested is not resumed
+	result := j.db.Model(&Job{}).
+		Scopes(claimable).
+		Where("cancel_requested = ?", true).
+		Updates(map[string]interface{}{
+			"status":           StatusCancelled,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+			"finished_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var candidates []Job
+	result = j.db.Scopes(claimable).Where("cancel_requested = ?", false).Order("id asc").Limit(8).Find(&candidates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, candidate := range candidates {
+		// the conditional update guarantees a single worker wins the claim
+		now := time.Now()
+		result = j.db.Model(&Job{}).
+			Where("id = ? AND cancel_requested = ?", candidate.ID, false).
+			Scopes(claimable).
+			Updates(map[string]interface{}{
+				"status":           StatusRunning,
+				"attempts":         gorm.Expr("attempts + 1"),
+				"lease_owner":      owner,
+				"lease_expires_at": now.Add(lease),
+				"started_at":       gorm.Expr("COALESCE(started_at, ?)", now),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return j.GetByUUID(candidate.JobId)
+		}
+	}
+	return nil, nil
+}
+
+func (j jobRepository) Heartbeat(job *Job, lease time.Duration) (bool, error) {
+	expiresAt := time.Now().Add(lease)
+	result := j.db.Model(&Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, StatusRunning, job.LeaseOwner).
+		Updates(map[string]interface{}{
+			"progress":         job.Progress,
+			"lease_expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, gorm.ErrRecordNotFound
+	}
+	job.LeaseExpiresAt = &expiresAt
+
+	current := Job{}
+	result = j.db.Select("cancel_requested").Where("id = ?", job.ID).Find(&current)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	job.CancelRequested = current.CancelRequested
+	return current.CancelRequested, nil
+}
+
+func (j jobRepository) Release(job *Job) error {
+	result := j.db.Model(&Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, StatusRunning, job.LeaseOwner).
+		Updates(map[string]interface{}{
+			"status":           StatusQueued,
+			"attempts":         gorm.Expr("attempts - 1"),
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (j jobRepository) StoreResult(job *Job, resultId string) error {
+	result := j.db.Model(&Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, StatusRunning, job.LeaseOwner).
+		Update("result_id", resultId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (j jobRepository) Finish(job *Job) error {
+	now := time.Now()
+	job.FinishedAt = &now
+	result := j.db.Model(&Job{}).
+		Where("id = ? AND status = ?", job.ID, StatusRunning).
+		Updates(map[string]interface{}{
+			"status":           job.Status,
+			"progress":         job.Progress,
+			"result_id":        job.ResultId,
+			"error":            job.Error,
+			"code":             job.Code,
+			"error_code":       job.ErrorCode,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+			"finished_at":      job.FinishedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (j jobRepository) Cancel(job *Job) error {
+	// queued jobs are cancelled right away
+	result := j.db.Model(&Job{}).
+		Where("id = ? AND status = ?", job.ID, StatusQueued).
+		Updates(map[string]interface{}{
+			"status":           StatusCancelled,
+			"cancel_requested": true,
+			"finished_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// running jobs are cancelled by their worker on the next heartbeat
+	if result.RowsAffected == 0 {
+		result = j.db.Model(&Job{}).
+			Where("id = ? AND status = ?", job.ID, StatusRunning).
+			Update("cancel_requested", true)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return j.db.Where("id = ?", job.ID).First(job).Error
+}
@@ -1,7 +1,14 @@
 package models
 
 import (
+	"github.com/pedromspeixoto/users-api/internal/data/models/audit"
+	"github.com/pedromspeixoto/users-api/internal/data/models/idempotency"
+	"github.com/pedromspeixoto/users-api/internal/data/models/jobs"
+	"github.com/pedromspeixoto/users-api/internal/data/models/outbox"
+	"github.com/pedromspeixoto/users-api/internal/data/models/shares"
+	"github.com/pedromspeixoto/users-api/internal/data/models/storage"
 	"github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/data/models/webhooks"
 	"go.uber.org/fx"
 )
 
@@ -10,6 +17,17 @@ func ProvideModels() fx.Option {
 		fx.Provide(
 			users.NewUserRepository,
 			users.NewUserFileRepository,
+			users.NewUserFileTextRepository,
+			users.NewUserFileVersionRepository,
+			shares.NewFileShareRepository,
+			storage.NewFileBlobRepository,
+			storage.NewFileThumbnailRepository,
+			audit.NewAuditEventRepository,
+			outbox.NewOutboxRepository,
+			idempotency.NewIdempotencyKeyRepository,
+			jobs.NewJobRepository,
+			webhooks.NewWebhookSubscriptionRepository,
+			webhooks.NewWebhookDeliveryRepository,
 		),
 	)
 }
@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event waiting to be relayed to the configured sinks.
+// It is written in the same transaction as the change it describes.
+type OutboxEvent struct {
+	ID            uint `gorm:"primarykey"`
+	EventId       string
+	EventType     string
+	AggregateType string
+	AggregateId   string
+	Payload       string
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	PublishedAt   *time.Time
+	DeadAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// OutboxRepository is a repository for dealing with outbox events.
+type OutboxRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) OutboxRepository
+	// Create creates an outbox event in the database.
+	Create(event *OutboxEvent) error
+	// Claim claims up to limit unpublished events due for an attempt, oldest first,
+	// and holds them for lease so other relays skip them.
+	Claim(limit int, lease time.Duration) ([]OutboxEvent, error)
+	// MarkPublished marks an event as published.
+	MarkPublished(event *OutboxEvent) error
+	// MarkFailed records a failed attempt and schedules the next one.
+	MarkFailed(event *OutboxEvent, cause error, nextAttemptAt time.Time) error
+	// MarkDead records a last failed attempt and stops relaying the event.
+	MarkDead(event *OutboxEvent, cause error) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (o outboxRepository) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: tx,
+	}
+}
+
+func (o outboxRepository) Create(event *OutboxEvent) error {
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now()
+	}
+	result := o.db.Create(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (o outboxRepository) Claim(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	pending := func(db *gorm.DB) *gorm.DB {
+		return db.Where("published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", time.Now())
+	}
+
+	var candidates []OutboxEvent
+	result := o.db.Scopes(pending).Order("id asc").Limit(limit).Find(&candidates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	events := make([]OutboxEvent, 0, len(candidates))
+	for _, candidate := range candidates {
+		// the conditional update guarantees a single relay wins the claim
+		leaseExpiresAt := time.Now().Add(lease)
+		result = o.db.Model(&OutboxEvent{}).
+			Where("id = ?", candidate.ID).
+			Scopes(pending).
+			Update("next_attempt_at", leaseExpiresAt)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			candidate.NextAttemptAt = leaseExpiresAt
+			events = append(events, candidate)
+		}
+	}
+	return events, nil
+}
+
+func (o outboxRepository) MarkPublished(event *OutboxEvent) error {
+	now := time.Now()
+	result := o.db.Model(event).Updates(map[string]interface{}{
+		"published_at": now,
+		"attempts":     event.Attempts + 1,
+		"last_error":   nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (o outboxRepository) MarkFailed(event *OutboxEvent, cause error, nextAttemptAt time.Time) error {
+	result := o.db.Model(event).Updates(map[string]interface{}{
+		"attempts":        event.Attempts + 1,
+		"last_error":      cause.Error(),
+		"next_attempt_at": nextAttemptAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (o outboxRepository) MarkDead(event *OutboxEvent, cause error) error {
+	result := o.db.Model(event).Updates(map[string]interface{}{
+		"attempts":   event.Attempts + 1,
+		"last_error": cause.Error(),
+		"dead_at":    time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
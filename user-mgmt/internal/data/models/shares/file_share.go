@@ -0,0 +1,123 @@
+package shares
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+// FileShare grants access to the content of a user file to whoever holds its signed
+// link, until it expires, its downloads run out or it is revoked.
+type FileShare struct {
+	ID            uint `gorm:"primarykey"`
+	ShareId       string
+	FileId        string
+	UserId        string
+	CreatedBy     string
+	ExpiresAt     time.Time
+	MaxDownloads  *int
+	DownloadCount int
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// FileShareRepository is a repository for dealing with file shares.
+type FileShareRepository interface {
+	// List lists the shares of a file with pagination, newest first.
+	List(fileId string, limit, page int) ([]FileShare, *data.Pagination, error)
+	// GetByUUID gets a share from the database by uuid.
+	GetByUUID(uuid string) (*FileShare, error)
+	// Create creates a share in the database.
+	Create(share *FileShare) error
+	// Revoke revokes a share, keeping it for the record.
+	Revoke(share *FileShare) error
+	// CountDownload counts a download of a share unless it is revoked, expired or has
+	// no download left. It returns whether the download was counted.
+	CountDownload(uuid string) (bool, error)
+}
+
+type fileShareRepository struct {
+	db *gorm.DB
+}
+
+func NewFileShareRepository(db *gorm.DB) FileShareRepository {
+	return &fileShareRepository{
+		db: db,
+	}
+}
+
+func (f fileShareRepository) List(fileId string, limit, page int) ([]FileShare, *data.Pagination, error) {
+	var shares []FileShare
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+		Sort:  "created_at desc, id desc",
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Where("file_id = ?", fileId)
+	}
+
+	result := f.db.Scopes(scope, pagination.Paginate()).Find(&shares)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	result = f.db.Model(&FileShare{}).Scopes(scope).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return shares, pagination, nil
+}
+
+func (f fileShareRepository) GetByUUID(uuid string) (*FileShare, error) {
+	share := FileShare{}
+	result := f.db.Where("share_id = ?", uuid).Find(&share)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &share, nil
+}
+
+func (f fileShareRepository) Create(share *FileShare) error {
+	result := f.db.Create(share)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f fileShareRepository) Revoke(share *FileShare) error {
+	now := time.Now()
+	result := f.db.Model(share).Where("revoked_at IS NULL").Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if share.RevokedAt == nil {
+		share.RevokedAt = &now
+	}
+	return nil
+}
+
+func (f fileShareRepository) CountDownload(uuid string) (bool, error) {
+	// checked and counted at once, so concurrent downloads cannot exceed the maximum
+	result := f.db.Model(&FileShare{}).
+		Where("share_id = ? AND revoked_at IS NULL AND expires_at > ?", uuid, time.Now()).
+		Where("max_downloads IS NULL OR download_count < max_downloads").
+		Update("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
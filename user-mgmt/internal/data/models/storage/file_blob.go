@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileBlob is file content stored once per distinct SHA-256 digest, shared by every
+// user file with that content. RefCount is the number of user files referencing it.
+// Content is encrypted with a data key, stored in WrappedKey wrapped by the master
+// key KeyId; blobs stored before encryption was introduced have no KeyId.
+type FileBlob struct {
+	ID         uint `gorm:"primarykey"`
+	Sha256     string
+	Size       int64
+	Content    []byte
+	KeyId      *string
+	WrappedKey []byte
+	RefCount   int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// FileBlobRepository is a repository for dealing with file blobs.
+type FileBlobRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) FileBlobRepository
+	// GetBySha256 gets a blob from the database by digest.
+	GetBySha256(sha256 string) (*FileBlob, error)
+	// Acquire adds a reference to the blob with the same digest, creating it when missing.
+	Acquire(blob *FileBlob) error
+	// Release removes a reference to the blob with the given digest, deleting it
+	// when no reference is left. It returns whether the blob was deleted.
+	Release(sha256 string) (bool, error)
+	// ListNotWrappedBy lists up to limit blobs with an id above afterId whose data key
+	// is not wrapped by the master key keyId, without their content.
+	ListNotWrappedBy(keyId string, afterId uint, limit int) ([]FileBlob, error)
+	// UpdateKey stores the key, and the content when set, of blob unless its key was
+	// changed from previousKeyId in the meantime. It returns whether it was stored.
+	UpdateKey(blob *FileBlob, previousKeyId *string) (bool, error)
+}
+
+type fileBlobRepository struct {
+	db *gorm.DB
+}
+
+func NewFileBlobRepository(db *gorm.DB) FileBlobRepository {
+	return &fileBlobRepository{
+		db: db,
+	}
+}
+
+func (f fileBlobRepository) WithTx(tx *gorm.DB) FileBlobRepository {
+	return &fileBlobRepository{
+		db: tx,
+	}
+}
+
+func (f fileBlobRepository) GetBySha256(sha256 string) (*FileBlob, error) {
+	blob := FileBlob{}
+	result := f.db.Where("sha256 = ?", sha256).Find(&blob)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &blob, nil
+}
+
+func (f fileBlobRepository) Acquire(blob *FileBlob) error {
+	// most of the time the content is already stored, avoid sending it again
+	result := f.db.Model(&FileBlob{}).
+		Where("sha256 = ?", blob.Sha256).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// a concurrent insert of the same content turns into a new reference
+	blob.RefCount = 1
+	result = f.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sha256"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}),
+	}).Create(blob)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f fileBlobRepository) Release(sha256 string) (bool, error) {
+	result := f.db.Model(&FileBlob{}).
+		Where("sha256 = ? AND ref_count > 0", sha256).
+		Update("ref_count", gorm.Expr("ref_count - 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	result = f.db.Where("sha256 = ? AND ref_count <= 0", sha256).Delete(&FileBlob{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (f fileBlobRepository) ListNotWrappedBy(keyId string, afterId uint, limit int) ([]FileBlob, error) {
+	var blobs []FileBlob
+	result := f.db.Select("id", "sha256", "key_id", "wrapped_key").
+		Where("(key_id IS NULL OR key_id <> ?) AND id > ?", keyId, afterId).
+		Order("id asc").
+		Limit(limit).
+		Find(&blobs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return blobs, nil
+}
+
+func (f fileBlobRepository) UpdateKey(blob *FileBlob, previousKeyId *string) (bool, error) {
+	updates := map[string]interface{}{
+		"key_id":      blob.KeyId,
+		"wrapped_key": blob.WrappedKey,
+		"updated_at":  time.Now(),
+	}
+	if blob.Content != nil {
+		updates["content"] = blob.Content
+	}
+	result := f.db.Model(&FileBlob{}).
+		Where("id = ? AND key_id <=> ?", blob.ID, previousKeyId).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
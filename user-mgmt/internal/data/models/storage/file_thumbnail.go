@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileThumbnail is a cached preview of the file content with digest Sha256, at most
+// Size pixels wide and high. Like blobs, Content is encrypted with a data key wrapped
+// by the master key KeyId.
+type FileThumbnail struct {
+	ID          uint `gorm:"primarykey"`
+	Sha256      string
+	Size        int
+	ContentType string
+	Content     []byte
+	KeyId       *string
+	WrappedKey  []byte
+	CreatedAt   time.Time
+}
+
+// FileThumbnailRepository is a repository for dealing with file thumbnails.
+type FileThumbnailRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) FileThumbnailRepository
+	// Get gets the thumbnail of a size for the content with the given digest.
+	Get(sha256 string, size int) (*FileThumbnail, error)
+	// Create creates a thumbnail in the database, keeping any existing one.
+	Create(thumbnail *FileThumbnail) error
+	// DeleteBySha256 deletes every thumbnail of the content with the given digest.
+	DeleteBySha256(sha256 string) error
+	// DeleteNotWrappedBy deletes every thumbnail whose data key is not wrapped by
+	// the master key keyId, returning how many were deleted.
+	DeleteNotWrappedBy(keyId string) (int64, error)
+}
+
+type fileThumbnailRepository struct {
+	db *gorm.DB
+}
+
+func NewFileThumbnailRepository(db *gorm.DB) FileThumbnailRepository {
+	return &fileThumbnailRepository{
+		db: db,
+	}
+}
+
+func (f fileThumbnailRepository) WithTx(tx *gorm.DB) FileThumbnailRepository {
+	return &fileThumbnailRepository{
+		db: tx,
+	}
+}
+
+func (f fileThumbnailRepository) Get(sha256 string, size int) (*FileThumbnail, error) {
+	thumbnail := FileThumbnail{}
+	result := f.db.Where("sha256 = ? AND size = ?", sha256, size).Find(&thumbnail)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &thumbnail, nil
+}
+
+func (f fileThumbnailRepository) Create(thumbnail *FileThumbnail) error {
+	// concurrent requests may render the same thumbnail, the first one wins
+	result := f.db.Clauses(clause.OnConflict{DoNothing: true}).Create(thumbnail)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f fileThumbnailRepository) DeleteBySha256(sha256 string) error {
+	result := f.db.Where("sha256 = ?", sha256).Delete(&FileThumbnail{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f fileThumbnailRepository) DeleteNotWrappedBy(keyId string) (int64, error) {
+	result := f.db.Where("key_id IS NULL OR key_id <> ?", keyId).Delete(&FileThumbnail{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -2,19 +2,24 @@ package users
 
 import (
 	"math"
+	"time"
 
 	"github.com/pedromspeixoto/users-api/internal/data"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type User struct {
 	gorm.Model
-	UserId string
-	Email  string
+	UserId          string
+	Email           string
+	EmailVerifiedAt *time.Time
 }
 
 // UserRepository is a repository for dealing with the user object.
 type UserRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) UserRepository
 	// List lists users from the database with pagination.
 	List(limit, page int) ([]User, *data.Pagination, error)
 	// GetByUUID gets a user from the database by uuid.
@@ -23,10 +28,16 @@ type UserRepository interface {
 	Get(id uint) (*User, error)
 	// Create creates a user in the database.
 	Create(user *User) error
+	// Update updates a user in the database.
+	Update(user *User) error
 	// SoftDelete soft deletes a user record from the database.
 	SoftDelete(user *User) error
 	// HardDelete hard deletes a user record from the database.
 	HardDelete(user *User) error
+	// Restore restores a soft deleted user record.
+	Restore(user *User) error
+	// Lock locks the user row by uuid until the end of the transaction the repository is bound to.
+	Lock(uuid string) error
 }
 
 type userRepository struct {
@@ -39,6 +50,12 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
+func (u userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{
+		db: tx,
+	}
+}
+
 func (u userRepository) List(limit, page int) ([]User, *data.Pagination, error) {
 	var users []User
 
@@ -85,6 +102,14 @@ func (u userRepository) Create(user *User) error {
 	return nil
 }
 
+func (u userRepository) Update(user *User) error {
+	result := u.db.Save(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
 func (u userRepository) SoftDelete(user *User) error {
 	result := u.db.Delete(user)
 	if result.Error != nil {
@@ -100,3 +125,19 @@ func (u userRepository) HardDelete(user *User) error {
 	}
 	return nil
 }
+
+func (u userRepository) Restore(user *User) error {
+	result := u.db.Unscoped().Model(user).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (u userRepository) Lock(uuid string) error {
+	result := u.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("user_id = ?", uuid).Find(&User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
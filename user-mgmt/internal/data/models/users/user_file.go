@@ -2,35 +2,106 @@ package users
 
 import (
 	"math"
+	"strings"
+	"time"
 
 	"github.com/pedromspeixoto/users-api/internal/data"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FileStatusAvailable   = "available"
+	FileStatusQuarantined = "quarantined"
 )
 
 type UserFile struct {
 	gorm.Model
-	UserId      string
-	FileId      string
+	UserId string
+	FileId string
+	// Filename is the original name of the file, if known.
+	Filename    *string
+	Description *string
 	FileType    string
-	FileContent []byte
+	FileSize    int64
+	// Status is quarantined when malware was detected in the content.
+	Status           string
+	QuarantineReason *string
+	// Sha256 is the digest of the file content, stored once per digest in file blobs.
+	Sha256 string
+	// Version is the number of the current version, whose content the file mirrors.
+	Version int
+	// ExpiresAt is when the file is deleted by the expiry sweeper, never when nil.
+	ExpiresAt *time.Time
+	Metadata  *UserFileMetadata `gorm:"foreignKey:FileId;references:FileId"`
+	Tags      []UserFileTag     `gorm:"foreignKey:FileId;references:FileId"`
+}
+
+// Expired reports whether the file expired at now.
+func (f *UserFile) Expired(now time.Time) bool {
+	return f.ExpiresAt != nil && !f.ExpiresAt.After(now)
+}
+
+// ExpiryCursor is the position of a file in the listing of expired files, ordered
+// by expiry then id.
+type ExpiryCursor struct {
+	ExpiresAt time.Time
+	ID        uint
+}
+
+// TagNames returns the tags of the file.
+func (f *UserFile) TagNames() []string {
+	tags := make([]string, 0, len(f.Tags))
+	for _, tag := range f.Tags {
+		tags = append(tags, tag.Tag)
+	}
+	return tags
 }
 
 // UserFileRepository is a repository for dealing with user files.
 type UserFileRepository interface {
-	// List user files from the database with pagination.
-	List(userId string, limit, page int) ([]UserFile, *data.Pagination, error)
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) UserFileRepository
+	// List user files from the database with pagination, optionally filtered. Expired
+	// files are left out, even before they are swept.
+	List(userId string, filter *UserFileFilter, limit, page int) ([]UserFile, *data.Pagination, error)
+	// ListAfter lists up to limit files of a user with an id above afterId, optionally
+	// filtered, in id order. Expired files are left out like in List.
+	ListAfter(userId string, filter *UserFileFilter, afterId uint, limit int) ([]UserFile, error)
 	// GetByUserUUID gets a file from the database by user uuid.
 	GetByUserUUID(uuid string) (*UserFile, error)
 	// GetByUUID gets a file from the database by uuid.
 	GetByUUID(uuid string) (*UserFile, error)
 	// Get gets a file from the database by id.
 	Get(id uint) (*UserFile, error)
+	// GetForUpdate gets a file by uuid, not deleted, and locks its row until the end of
+	// the transaction the repository is bound to.
+	GetForUpdate(uuid string) (*UserFile, error)
+	// GetUnscopedForUpdate gets a file by uuid, deleted or not, and locks its row until
+	// the end of the transaction the repository is bound to.
+	GetUnscopedForUpdate(uuid string) (*UserFile, error)
 	// Create creates a file in the database.
 	Create(file *UserFile) error
+	// UpdateVersion stores the current version of a file, replacing its metadata.
+	UpdateVersion(file *UserFile) error
+	// UpdateDetails stores the filename, description and expiry of a file, replacing its tags.
+	UpdateDetails(file *UserFile) error
+	// ListExpired lists up to limit files, not deleted, that expired at now, the
+	// earliest expiry first, after the given cursor when not nil.
+	ListExpired(now time.Time, after *ExpiryCursor, limit int) ([]UserFile, error)
 	// SoftDelete soft deletes a file from the database.
 	SoftDelete(file *UserFile) error
-	// HardDelete hard deletes a file from the database.
+	// Expire soft deletes a file if it is still expired at now and not deleted. It
+	// returns whether the file was deleted.
+	Expire(file *UserFile, now time.Time) (bool, error)
+	// HardDelete hard deletes a file from the database. It returns gorm.ErrRecordNotFound
+	// when the file no longer exists.
 	HardDelete(file *UserFile) error
+	// Restore restores a soft deleted file, storing its expiry.
+	Restore(file *UserFile) error
+	// Usage counts the files of a user that are not deleted and the total size in bytes
+	// of their versions.
+	Usage(userId string) (int64, int64, error)
 }
 
 type userFileRepository struct {
@@ -43,23 +114,53 @@ func NewUserFileRepository(db *gorm.DB) UserFileRepository {
 	}
 }
 
-func (f userFileRepository) List(userId string, limit, page int) ([]UserFile, *data.Pagination, error) {
+func (f userFileRepository) WithTx(tx *gorm.DB) UserFileRepository {
+	return &userFileRepository{
+		db: tx,
+	}
+}
+
+func (f userFileRepository) List(userId string, filter *UserFileFilter, limit, page int) ([]UserFile, *data.Pagination, error) {
 	var userFiles []UserFile
 
 	// pagination object
 	pagination := &data.Pagination{
 		Limit: limit,
 		Page:  page,
+		Sort:  "user_files.created_at asc, user_files.id asc",
+	}
+
+	scope := filterFiles(userId, filter)
+
+	result := f.db.Preload("Metadata").Preload("Tags", orderTags).Scopes(scope, pagination.Paginate()).Find(&userFiles)
+	if result.Error != nil {
+		return nil, nil, result.Error
 	}
-	f.db.Scopes(pagination.Paginate()).Find(&userFiles).Where("user_id = ?", userId)
 
 	// pagination details
-	f.db.Model(&UserFile{}).Count(&pagination.TotalRows)
+	result = f.db.Model(&UserFile{}).Scopes(scope).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
 	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
 
 	return userFiles, pagination, nil
 }
 
+func (f userFileRepository) ListAfter(userId string, filter *UserFileFilter, afterId uint, limit int) ([]UserFile, error) {
+	var userFiles []UserFile
+	result := f.db.Preload("Metadata").Preload("Tags", orderTags).
+		Scopes(filterFiles(userId, filter)).
+		Where("user_files.id > ?", afterId).
+		Order("user_files.id asc").
+		Limit(limit).
+		Find(&userFiles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userFiles, nil
+}
+
 func (f userFileRepository) GetByUserUUID(uuid string) (*UserFile, error) {
 	userFile := UserFile{}
 	result := f.db.Unscoped().Where("user_id = ?", uuid).Find(&userFile)
@@ -74,7 +175,7 @@ func (f userFileRepository) GetByUserUUID(uuid string) (*UserFile, error) {
 
 func (f userFileRepository) GetByUUID(uuid string) (*UserFile, error) {
 	userFile := UserFile{}
-	result := f.db.Unscoped().Where("file_id = ?", uuid).Find(&userFile)
+	result := f.db.Unscoped().Preload("Metadata").Preload("Tags", orderTags).Where("file_id = ?", uuid).Find(&userFile)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -93,6 +194,30 @@ func (f userFileRepository) Get(id uint) (*UserFile, error) {
 	return &userFile, nil
 }
 
+func (f userFileRepository) GetForUpdate(uuid string) (*UserFile, error) {
+	userFile := UserFile{}
+	result := f.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("file_id = ?", uuid).Find(&userFile)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &userFile, nil
+}
+
+func (f userFileRepository) GetUnscopedForUpdate(uuid string) (*UserFile, error) {
+	userFile := UserFile{}
+	result := f.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("file_id = ?", uuid).Find(&userFile)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &userFile, nil
+}
+
 func (f userFileRepository) Create(userFile *UserFile) error {
 	result := f.db.Create(userFile)
 	if result.Error != nil {
@@ -101,6 +226,62 @@ func (f userFileRepository) Create(userFile *UserFile) error {
 	return nil
 }
 
+func (f userFileRepository) UpdateVersion(userFile *UserFile) error {
+	result := f.db.Model(userFile).Select("file_type", "file_size", "sha256", "version", "status", "quarantine_reason", "updated_at").Updates(userFile)
+	if result.Error != nil {
+		return result.Error
+	}
+	result = f.db.Where("file_id = ?", userFile.FileId).Delete(&UserFileMetadata{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if userFile.Metadata == nil {
+		return nil
+	}
+	userFile.Metadata.FileId = userFile.FileId
+	result = f.db.Create(userFile.Metadata)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileRepository) UpdateDetails(userFile *UserFile) error {
+	result := f.db.Model(userFile).Select("filename", "description", "expires_at", "updated_at").Updates(userFile)
+	if result.Error != nil {
+		return result.Error
+	}
+	result = f.db.Where("file_id = ?", userFile.FileId).Delete(&UserFileTag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if len(userFile.Tags) == 0 {
+		return nil
+	}
+	for i := range userFile.Tags {
+		userFile.Tags[i].FileId = userFile.FileId
+	}
+	result = f.db.Create(&userFile.Tags)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileRepository) ListExpired(now time.Time, after *ExpiryCursor, limit int) ([]UserFile, error) {
+	db := f.db.Where("expires_at <= ?", now)
+	if after != nil {
+		db = db.Where("expires_at > ? OR (expires_at = ? AND id > ?)", after.ExpiresAt, after.ExpiresAt, after.ID)
+	}
+
+	var userFiles []UserFile
+	result := db.Order("expires_at asc, id asc").Limit(limit).Find(&userFiles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userFiles, nil
+}
+
 func (f userFileRepository) SoftDelete(userFile *UserFile) error {
 	result := f.db.Delete(userFile)
 	if result.Error != nil {
@@ -109,10 +290,107 @@ func (f userFileRepository) SoftDelete(userFile *UserFile) error {
 	return nil
 }
 
+func (f userFileRepository) Expire(userFile *UserFile, now time.Time) (bool, error) {
+	result := f.db.Where("expires_at <= ?", now).Delete(userFile)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (f userFileRepository) HardDelete(userFile *UserFile) error {
-	result := f.db.Unscoped().Delete(userFile)
+	result := f.db.Where("file_id = ?", userFile.FileId).Delete(&UserFileMetadata{})
+	if result.Error != nil {
+		return result.Error
+	}
+	result = f.db.Where("file_id = ?", userFile.FileId).Delete(&UserFileTag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	result = f.db.Unscoped().Delete(userFile)
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 	return nil
 }
+
+func (f userFileRepository) Restore(userFile *UserFile) error {
+	result := f.db.Unscoped().Model(userFile).Select("deleted_at", "expires_at").Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"expires_at": userFile.ExpiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileRepository) Usage(userId string) (int64, int64, error) {
+	usage := struct {
+		Files int64
+		Bytes int64
+	}{}
+	result := f.db.Model(&UserFile{}).
+		Select("COUNT(DISTINCT user_files.id) AS files, COALESCE(SUM(user_file_versions.file_size), 0) AS bytes").
+		Joins("LEFT JOIN user_file_versions ON user_file_versions.file_id = user_files.file_id").
+		Where("user_files.user_id = ?", userId).
+		Scan(&usage)
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	return usage.Files, usage.Bytes, nil
+}
+
+// filterFiles scopes a query to the files of a user matching filter, which may be
+// nil, leaving out expired files.
+func filterFiles(userId string, filter *UserFileFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_files.user_id = ?", userId).
+			Where("user_files.expires_at IS NULL OR user_files.expires_at > ?", time.Now())
+		if filter == nil {
+			return db
+		}
+		if filter.FileType != "" {
+			db = db.Where("user_files.file_type = ?", filter.FileType)
+		}
+		for _, tag := range filter.Tags {
+			db = db.Where("EXISTS (SELECT 1 FROM user_file_tags WHERE user_file_tags.file_id = user_files.file_id AND user_file_tags.tag = ?)", tag)
+		}
+		if !filter.hasMetadata() {
+			return db
+		}
+		db = db.Joins("JOIN user_file_metadata ON user_file_metadata.file_id = user_files.file_id")
+		if filter.MinPages != nil {
+			db = db.Where("user_file_metadata.page_count >= ?", *filter.MinPages)
+		}
+		if filter.MaxPages != nil {
+			db = db.Where("user_file_metadata.page_count <= ?", *filter.MaxPages)
+		}
+		if filter.Title != "" {
+			db = db.Where("user_file_metadata.title LIKE ?", "%"+escapeLike(filter.Title)+"%")
+		}
+		if filter.Author != "" {
+			db = db.Where("user_file_metadata.author LIKE ?", "%"+escapeLike(filter.Author)+"%")
+		}
+		if filter.Encrypted != nil {
+			db = db.Where("user_file_metadata.encrypted = ?", *filter.Encrypted)
+		}
+		return db
+	}
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, with the default escape
+// character of MySQL.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes value to match it literally in a LIKE pattern.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+func orderTags(db *gorm.DB) *gorm.DB {
+	return db.Order("tag asc")
+}
@@ -0,0 +1,40 @@
+package users
+
+import (
+	"time"
+)
+
+// UserFileMetadata is the metadata extracted from the content of a user file. Only
+// PDF documents have metadata for now.
+type UserFileMetadata struct {
+	ID           uint `gorm:"primarykey"`
+	FileId       string
+	PageCount    int
+	Title        string
+	Author       string
+	PdfCreatedAt *time.Time
+	Encrypted    bool
+	PdfVersion   string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (UserFileMetadata) TableName() string {
+	return "user_file_metadata"
+}
+
+// UserFileFilter narrows down the user files returned by a list.
+type UserFileFilter struct {
+	FileType string
+	// Tags only keeps the files with every one of them.
+	Tags      []string
+	MinPages  *int
+	MaxPages  *int
+	Title     string
+	Author    string
+	Encrypted *bool
+}
+
+func (f *UserFileFilter) hasMetadata() bool {
+	return f.MinPages != nil || f.MaxPages != nil || f.Title != "" || f.Author != "" || f.Encrypted != nil
+}
@@ -0,0 +1,13 @@
+package users
+
+import (
+	"time"
+)
+
+// UserFileTag is a label attached to a user file, files are filtered by tag.
+type UserFileTag struct {
+	ID        uint `gorm:"primarykey"`
+	FileId    string
+	Tag       string
+	CreatedAt time.Time
+}
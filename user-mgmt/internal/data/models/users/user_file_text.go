@@ -0,0 +1,108 @@
+package users
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+// UserFileText is the text extracted from the content of a user file, indexed for
+// full-text search.
+type UserFileText struct {
+	ID        uint `gorm:"primarykey"`
+	FileId    string
+	UserId    string
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UserFileSearchResult is a user file matching a full-text search with its text.
+type UserFileSearchResult struct {
+	UserFile
+	Text  string
+	Score float64
+}
+
+// UserFileTextRepository is a repository for dealing with the text of user files.
+type UserFileTextRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) UserFileTextRepository
+	// Create creates the text of a file in the database.
+	Create(text *UserFileText) error
+	// DeleteByFileUUID deletes the text of a file by file uuid.
+	DeleteByFileUUID(uuid string) error
+	// Search lists the files of a user whose text matches query, best matches first.
+	Search(query string, userId string, limit, page int) ([]UserFileSearchResult, *data.Pagination, error)
+}
+
+type userFileTextRepository struct {
+	db *gorm.DB
+}
+
+func NewUserFileTextRepository(db *gorm.DB) UserFileTextRepository {
+	return &userFileTextRepository{
+		db: db,
+	}
+}
+
+func (f userFileTextRepository) WithTx(tx *gorm.DB) UserFileTextRepository {
+	return &userFileTextRepository{
+		db: tx,
+	}
+}
+
+func (f userFileTextRepository) Create(text *UserFileText) error {
+	result := f.db.Create(text)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileTextRepository) DeleteByFileUUID(uuid string) error {
+	result := f.db.Where("file_id = ?", uuid).Delete(&UserFileText{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileTextRepository) Search(query string, userId string, limit, page int) ([]UserFileSearchResult, *data.Pagination, error) {
+	var results []UserFileSearchResult
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+		Sort:  "score desc, user_files.id desc",
+	}
+
+	match := "MATCH(user_file_texts.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Joins("JOIN user_file_texts ON user_file_texts.file_id = user_files.file_id").
+			Where(match, query).
+			Where("user_files.user_id = ?", userId).
+			Where("user_files.expires_at IS NULL OR user_files.expires_at > ?", time.Now())
+		return db
+	}
+
+	result := f.db.Model(&UserFile{}).
+		Select("user_files.*, user_file_texts.content AS text, "+match+" AS score", query).
+		Scopes(scope, pagination.Paginate()).
+		Find(&results)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	result = f.db.Model(&UserFile{}).Scopes(scope).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return results, pagination, nil
+}
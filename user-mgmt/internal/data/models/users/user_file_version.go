@@ -0,0 +1,149 @@
+package users
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+// UserFileVersion is the content of a user file at some point of its history. The
+// user file mirrors its current version, every version holds a reference to its blob.
+type UserFileVersion struct {
+	ID       uint `gorm:"primarykey"`
+	FileId   string
+	Version  int
+	FileType string
+	FileSize int64
+	Sha256   string
+	// Status is quarantined when malware was detected in the content.
+	Status           string
+	QuarantineReason *string
+	CreatedAt        time.Time
+}
+
+// UserFileVersionRepository is a repository for dealing with user file versions.
+type UserFileVersionRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) UserFileVersionRepository
+	// List lists the versions of a file with pagination, newest first.
+	List(fileId string, limit, page int) ([]UserFileVersion, *data.Pagination, error)
+	// Get gets a version of a file from the database.
+	Get(fileId string, version int) (*UserFileVersion, error)
+	// Create creates a version in the database.
+	Create(version *UserFileVersion) error
+	// Size sums the size in bytes of every version of a file.
+	Size(fileId string) (int64, error)
+	// DeleteOlderThan deletes the versions of a file below version, returning the
+	// deleted versions.
+	DeleteOlderThan(fileId string, version int) ([]UserFileVersion, error)
+	// DeleteByFileUUID deletes every version of a file, returning the deleted versions.
+	DeleteByFileUUID(fileId string) ([]UserFileVersion, error)
+}
+
+type userFileVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewUserFileVersionRepository(db *gorm.DB) UserFileVersionRepository {
+	return &userFileVersionRepository{
+		db: db,
+	}
+}
+
+func (f userFileVersionRepository) WithTx(tx *gorm.DB) UserFileVersionRepository {
+	return &userFileVersionRepository{
+		db: tx,
+	}
+}
+
+func (f userFileVersionRepository) List(fileId string, limit, page int) ([]UserFileVersion, *data.Pagination, error) {
+	var versions []UserFileVersion
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+		Sort:  "version desc",
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Where("file_id = ?", fileId)
+	}
+
+	result := f.db.Scopes(scope, pagination.Paginate()).Find(&versions)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	result = f.db.Model(&UserFileVersion{}).Scopes(scope).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return versions, pagination, nil
+}
+
+func (f userFileVersionRepository) Get(fileId string, version int) (*UserFileVersion, error) {
+	fileVersion := UserFileVersion{}
+	result := f.db.Where("file_id = ? AND version = ?", fileId, version).Find(&fileVersion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &fileVersion, nil
+}
+
+func (f userFileVersionRepository) Create(version *UserFileVersion) error {
+	result := f.db.Create(version)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (f userFileVersionRepository) Size(fileId string) (int64, error) {
+	var size int64
+	result := f.db.Model(&UserFileVersion{}).
+		Select("COALESCE(SUM(file_size), 0)").
+		Where("file_id = ?", fileId).
+		Scan(&size)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return size, nil
+}
+
+func (f userFileVersionRepository) DeleteOlderThan(fileId string, version int) ([]UserFileVersion, error) {
+	var expired []UserFileVersion
+	result := f.db.Where("file_id = ? AND version < ?", fileId, version).Find(&expired)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return expired, f.delete(expired)
+}
+
+func (f userFileVersionRepository) DeleteByFileUUID(fileId string) ([]UserFileVersion, error) {
+	var versions []UserFileVersion
+	result := f.db.Where("file_id = ?", fileId).Find(&versions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return versions, f.delete(versions)
+}
+
+func (f userFileVersionRepository) delete(versions []UserFileVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	result := f.db.Delete(&versions)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package webhooks
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusDead      = "dead"
+)
+
+// WebhookDelivery is a single event to be delivered to a subscription.
+type WebhookDelivery struct {
+	ID             uint `gorm:"primarykey"`
+	DeliveryId     string
+	SubscriptionId string
+	EventId        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	ResponseStatus *int
+	LastError      *string
+	NextAttemptAt  time.Time
+	LeaseOwner     *string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookDeliveryRepository is a repository for dealing with webhook deliveries.
+type WebhookDeliveryRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) WebhookDeliveryRepository
+	// List lists deliveries, optionally filtered by subscription and status, newest first.
+	List(subscriptionId, status string, limit, page int) ([]WebhookDelivery, *data.Pagination, error)
+	// Claim claims up to limit pending deliveries due for an attempt, oldest first,
+	// for owner until lease passes, and counts the attempt.
+	Claim(owner string, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	// GetByUUID gets a delivery from the database by uuid.
+	GetByUUID(uuid string) (*WebhookDelivery, error)
+	// Create creates a delivery in the database.
+	Create(delivery *WebhookDelivery) error
+	// Record records the outcome of an attempt of a delivery and releases it. It
+	// returns gorm.ErrRecordNotFound when the delivery is no longer claimed by its
+	// lease owner.
+	Record(delivery *WebhookDelivery) error
+	// Retry schedules a dead delivery for a new round of attempts. It returns
+	// gorm.ErrRecordNotFound when the delivery is no longer dead.
+	Retry(delivery *WebhookDelivery) error
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: db,
+	}
+}
+
+func (w webhookDeliveryRepository) WithTx(tx *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: tx,
+	}
+}
+
+func (w webhookDeliveryRepository) List(subscriptionId, status string, limit, page int) ([]WebhookDelivery, *data.Pagination, error) {
+	var deliveries []WebhookDelivery
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+		Sort:  "created_at desc, id desc",
+	}
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		if subscriptionId != "" {
+			db = db.Where("subscription_id = ?", subscriptionId)
+		}
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	result := w.db.Scopes(filter, pagination.Paginate()).Find(&deliveries)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	result = w.db.Model(&WebhookDelivery{}).Scopes(filter).Count(&pagination.TotalRows)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return deliveries, pagination, nil
+}
+
+func (w webhookDeliveryRepository) Claim(owner string, lease time.Duration, limit int) ([]WebhookDelivery, error) {
+	due := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND next_attempt_at <= ?", DeliveryStatusPending, time.Now())
+	}
+
+	var candidates []WebhookDelivery
+	result := w.db.Scopes(due).Order("id asc").Limit(limit).Find(&candidates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	deliveries := make([]WebhookDelivery, 0, len(candidates))
+	for _, candidate := range candidates {
+		// the conditional update guarantees a single dispatcher wins the claim
+		leaseExpiresAt := time.Now().Add(lease)
+		result = w.db.Model(&WebhookDelivery{}).
+			Where("id = ?", candidate.ID).
+			Scopes(due).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"lease_owner":     owner,
+				"next_attempt_at": leaseExpiresAt,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			candidate.Attempts++
+			candidate.LeaseOwner = &owner
+			candidate.NextAttemptAt = leaseExpiresAt
+			deliveries = append(deliveries, candidate)
+		}
+	}
+	return deliveries, nil
+}
+
+func (w webhookDeliveryRepository) GetByUUID(uuid string) (*WebhookDelivery, error) {
+	delivery := WebhookDelivery{}
+	result := w.db.Where("delivery_id = ?", uuid).Find(&delivery)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &delivery, nil
+}
+
+func (w webhookDeliveryRepository) Create(delivery *WebhookDelivery) error {
+	result := w.db.Create(delivery)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (w webhookDeliveryRepository) Record(delivery *WebhookDelivery) error {
+	result := w.db.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", delivery.ID, DeliveryStatusPending, delivery.LeaseOwner).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"response_status": delivery.ResponseStatus,
+			"last_error":      delivery.LastError,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"lease_owner":     nil,
+			"delivered_at":    delivery.DeliveredAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	delivery.LeaseOwner = nil
+	return nil
+}
+
+func (w webhookDeliveryRepository) Retry(delivery *WebhookDelivery) error {
+	now := time.Now()
+	result := w.db.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ?", delivery.ID, DeliveryStatusDead).
+		Updates(map[string]interface{}{
+			"status":          DeliveryStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"lease_owner":     nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.LeaseOwner = nil
+	return nil
+}
@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"math"
+
+	"github.com/pedromspeixoto/users-api/internal/data"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is an url events are delivered to. Secret is encrypted with a
+// data key, stored in SecretWrappedKey wrapped by the master key SecretKeyId;
+// secrets stored before encryption was introduced have no SecretKeyId.
+type WebhookSubscription struct {
+	gorm.Model
+	SubscriptionId   string
+	Url              string
+	Secret           []byte
+	SecretKeyId      *string
+	SecretWrappedKey []byte
+	// EventTypes is a comma separated list of event types, empty matches all events.
+	EventTypes string
+	Active     bool
+}
+
+// WebhookSubscriptionRepository is a repository for dealing with webhook subscriptions.
+type WebhookSubscriptionRepository interface {
+	// WithTx returns a repository bound to the given transaction.
+	WithTx(tx *gorm.DB) WebhookSubscriptionRepository
+	// List lists subscriptions from the database with pagination.
+	List(limit, page int) ([]WebhookSubscription, *data.Pagination, error)
+	// ListActiveByEventType lists active subscriptions interested in the event type.
+	ListActiveByEventType(eventType string) ([]WebhookSubscription, error)
+	// GetByUUID gets a subscription from the database by uuid.
+	GetByUUID(uuid string) (*WebhookSubscription, error)
+	// Create creates a subscription in the database.
+	Create(subscription *WebhookSubscription) error
+	// Update updates a subscription in the database.
+	Update(subscription *WebhookSubscription) error
+	// SoftDelete soft deletes a subscription from the database.
+	SoftDelete(subscription *WebhookSubscription) error
+	// ListSecretsNotWrappedBy lists up to limit subscriptions with an id above afterId
+	// whose secret is not encrypted with a data key wrapped by the master key keyId.
+	ListSecretsNotWrappedBy(keyId string, afterId uint, limit int) ([]WebhookSubscription, error)
+	// UpdateSecret stores the secret and its key of a subscription.
+	UpdateSecret(subscription *WebhookSubscription) error
+}
+
+type webhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookSubscriptionRepository(db *gorm.DB) WebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{
+		db: db,
+	}
+}
+
+func (w webhookSubscriptionRepository) WithTx(tx *gorm.DB) WebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{
+		db: tx,
+	}
+}
+
+func (w webhookSubscriptionRepository) List(limit, page int) ([]WebhookSubscription, *data.Pagination, error) {
+	var subscriptions []WebhookSubscription
+
+	// pagination object
+	pagination := &data.Pagination{
+		Limit: limit,
+		Page:  page,
+	}
+	result := w.db.Scopes(pagination.Paginate()).Find(&subscriptions)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	// pagination details
+	w.db.Model(&WebhookSubscription{}).Count(&pagination.TotalRows)
+	pagination.TotalPages = int(math.Ceil(float64(pagination.TotalRows) / float64(pagination.GetLimit())))
+
+	return subscriptions, pagination, nil
+}
+
+func (w webhookSubscriptionRepository) ListActiveByEventType(eventType string) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	result := w.db.
+		Where("active = ?", true).
+		Where("event_types = '' OR FIND_IN_SET(?, event_types) > 0", eventType).
+		Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+func (w webhookSubscriptionRepository) GetByUUID(uuid string) (*WebhookSubscription, error) {
+	subscription := WebhookSubscription{}
+	result := w.db.Where("subscription_id = ?", uuid).Find(&subscription)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &subscription, nil
+}
+
+func (w webhookSubscriptionRepository) Create(subscription *WebhookSubscription) error {
+	result := w.db.Create(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (w webhookSubscriptionRepository) Update(subscription *WebhookSubscription) error {
+	result := w.db.Save(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (w webhookSubscriptionRepository) SoftDelete(subscription *WebhookSubscription) error {
+	result := w.db.Delete(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (w webhookSubscriptionRepository) ListSecretsNotWrappedBy(keyId string, afterId uint, limit int) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	result := w.db.
+		Where("(secret_key_id IS NULL OR secret_key_id <> ?) AND id > ?", keyId, afterId).
+		Order("id asc").
+		Limit(limit).
+		Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+func (w webhookSubscriptionRepository) UpdateSecret(subscription *WebhookSubscription) error {
+	result := w.db.Model(subscription).Select("secret", "secret_key_id", "secret_wrapped_key", "updated_at").Updates(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package apperrors defines the errors returned by the domain. Each error has a
+// kind, which decides how it is reported, and a stable code that clients can match
+// on; messages are meant for humans and may change.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Kind classifies errors by how they are reported.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthenticated
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindGone
+	KindUnprocessable
+	KindTooLarge
+	KindUnsupportedMediaType
+	KindQuotaExceeded
+	KindUnavailable
+	KindUpstream
+	KindUpstreamTimeout
+)
+
+// kindStatus maps the kinds of errors to the status codes of their HTTP responses.
+var kindStatus = map[Kind]int{
+	KindInternal:             http.StatusInternalServerError,
+	KindInvalid:              http.StatusBadRequest,
+	KindUnauthenticated:      http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindGone:                 http.StatusGone,
+	KindUnprocessable:        http.StatusUnprocessableEntity,
+	KindTooLarge:             http.StatusRequestEntityTooLarge,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindQuotaExceeded:        http.StatusInsufficientStorage,
+	KindUnavailable:          http.StatusServiceUnavailable,
+	KindUpstream:             http.StatusBadGateway,
+	KindUpstreamTimeout:      http.StatusGatewayTimeout,
+}
+
+// Stable error codes, never change the value of an existing code.
+const (
+	CodeInternal         = "internal_error"
+	CodeInvalidRequest   = "invalid_request"
+	CodeMalformedBody    = "malformed_body"
+	CodeValidationFailed = "validation_failed"
+	CodeRouteNotFound    = "route_not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeActorRequired    = "actor_required"
+	CodeRequestAborted   = "request_aborted"
+	CodeRequestTooLarge  = "request_too_large"
+
+	CodeIdempotencyKeyInvalid  = "idempotency_key_invalid"
+	CodeIdempotencyKeyInUse    = "idempotency_key_in_use"
+	CodeIdempotencyKeyMismatch = "idempotency_key_mismatch"
+
+	CodeUserNotFound       = "user_not_found"
+	CodeUserNotDeleted     = "user_not_deleted"
+	CodeInvalidVerifyToken = "invalid_verification_token"
+
+	CodeFileNotFound           = "file_not_found"
+	CodeFileDeleted            = "file_deleted"
+	CodeFileNotDeleted         = "file_not_deleted"
+	CodeFileQuarantined        = "file_quarantined"
+	CodeFileTooLarge           = "file_too_large"
+	CodeFileTypeNotAllowed     = "file_type_not_allowed"
+	CodeFileTypeMismatch       = "file_type_mismatch"
+	CodeFileCorrupted          = "file_corrupted"
+	CodeFileImportNotAllowed   = "file_import_not_allowed"
+	CodeFileServerUnavailable  = "file_server_unavailable"
+	CodeFileServerTimeout      = "file_server_timeout"
+	CodeFileServerFailure      = "file_server_failure"
+	CodeScannerUnavailable     = "scanner_unavailable"
+	CodeQuotaExceeded          = "quota_exceeded"
+	CodeThumbnailUnsupported   = "thumbnail_unsupported"
+	CodeVersionNotFound        = "version_not_found"
+	CodeVersionAlreadyCurrent  = "version_already_current"
+	CodeVersionQuarantined     = "version_quarantined"
+	CodeShareNotFound          = "share_not_found"
+	CodeShareRevoked           = "share_revoked"
+	CodeShareExpired           = "share_expired"
+	CodeShareExhausted         = "share_exhausted"
+	CodeShareInvalid           = "share_invalid"
+	CodeJobNotFound            = "job_not_found"
+	CodeJobFinished            = "job_finished"
+	CodeJobAbandoned           = "job_abandoned"
+	CodeWebhookNotFound        = "webhook_not_found"
+	CodeDeliveryNotFound       = "webhook_delivery_not_found"
+	CodeDeliveryNotRetryable   = "webhook_delivery_not_retryable"
+	CodeSearchQueryEmpty       = "search_query_empty"
+	CodeInvalidThumbnailSize   = "invalid_thumbnail_size"
+	CodeInvalidExpiry          = "invalid_expiry"
+	CodeInvalidShareExpiration = "invalid_share_expiration"
+)
+
+// Error is an error of the domain.
+type Error struct {
+	Kind Kind
+	Code string
+	// Message describes the error to clients.
+	Message string
+	// Fields lists the invalid fields of a request, if any.
+	Fields []FieldError
+	// Err is the cause of the error, it is not shown to clients.
+	Err error
+}
+
+// FieldError describes why a field of a request is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error of the given kind and code with a formatted message.
+func New(kind Kind, code string, format string, args ...interface{}) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Wrap is like New, keeping err as the cause.
+func Wrap(kind Kind, code string, err error, format string, args ...interface{}) *Error {
+	e := New(kind, code, format, args...)
+	e.Err = err
+	return e
+}
+
+// Internal returns an unexpected error, its cause is only logged.
+func Internal(err error, format string, args ...interface{}) *Error {
+	return Wrap(KindInternal, CodeInternal, err, format, args...)
+}
+
+func Invalid(code string, format string, args ...interface{}) *Error {
+	return New(KindInvalid, code, format, args...)
+}
+
+func NotFound(code string, format string, args ...interface{}) *Error {
+	return New(KindNotFound, code, format, args...)
+}
+
+func Conflict(code string, format string, args ...interface{}) *Error {
+	return New(KindConflict, code, format, args...)
+}
+
+func Forbidden(code string, format string, args ...interface{}) *Error {
+	return New(KindForbidden, code, format, args...)
+}
+
+func Gone(code string, format string, args ...interface{}) *Error {
+	return New(KindGone, code, format, args...)
+}
+
+// Lookup classifies the error of fetching a record: a missing record is reported as
+// not found with code and the formatted message, any other failure as internal.
+func Lookup(err error, code string, format string, args ...interface{}) *Error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Wrap(KindNotFound, code, err, format, args...)
+	}
+	return Internal(err, "unexpected error fetching record")
+}
+
+// MalformedBody returns the error of a request body that could not be decoded.
+func MalformedBody(err error) *Error {
+	return Invalid(CodeMalformedBody, "malformed request body: %v", err)
+}
+
+// InvalidRequest returns the error of a request with invalid parameters.
+func InvalidRequest(err error) *Error {
+	return Invalid(CodeInvalidRequest, "%v", err)
+}
+
+// As returns the domain error in the chain of err. Validation errors are reported
+// with the invalid fields, and any other error as an internal error.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return Validation(validationErrs)
+	}
+	return Internal(err, "unexpected error")
+}
+
+// Validation lists the fields that failed validation, named after their json keys
+// without the name of the request.
+func Validation(validationErrs validator.ValidationErrors) *Error {
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		field := fieldErr.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+
+		rule := fieldErr.Tag()
+		message := fmt.Sprintf("%s failed the %s rule", field, rule)
+		if fieldErr.Param() != "" {
+			message = fmt.Sprintf("%s failed the %s=%s rule", field, rule, fieldErr.Param())
+		}
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    rule,
+			Message: message,
+		})
+	}
+
+	err := Invalid(CodeValidationFailed, "request validation failed")
+	err.Fields = fields
+	return err
+}
+
+// HTTPStatus returns the status code of the HTTP response reporting err.
+func HTTPStatus(err error) int {
+	if status, ok := kindStatus[As(err).Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Is reports whether err is a domain error with the given code.
+func Is(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
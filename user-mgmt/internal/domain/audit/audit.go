@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	auditmodel "github.com/pedromspeixoto/users-api/internal/data/models/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	auditdto "github.com/pedromspeixoto/users-api/internal/dto/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"go.uber.org/fx"
+)
+
+const (
+	TargetTypeUser = "user"
+	TargetTypeFile = "file"
+)
+
+const (
+	ActionUserCreate         = "user.create"
+	ActionUserDelete         = "user.delete"
+	ActionUserRestore        = "user.restore"
+	ActionUserVerify         = "user.verify"
+	ActionFileCreate         = "file.create"
+	ActionFileUpdate         = "file.update"
+	ActionFileDelete         = "file.delete"
+	ActionFileExpire         = "file.expire"
+	ActionFileRestore        = "file.restore"
+	ActionFilePurge          = "file.purge"
+	ActionFileQuarantine     = "file.quarantine"
+	ActionFileDownload       = "file.download"
+	ActionFileArchive        = "file.archive"
+	ActionFileVersionCreate  = "file.version.create"
+	ActionFileVersionRestore = "file.version.restore"
+	ActionFileShare          = "file.share"
+	ActionFileShareRevoke    = "file.share.revoke"
+)
+
+// AuditService provides methods pertaining to recording and querying audit events.
+type AuditService interface {
+	// Record appends an audit event for the actor found in ctx. before and after are
+	// snapshots of the target and are stored as json, either one may be nil.
+	Record(ctx context.Context, action, targetType, targetId string, before, after interface{}) error
+	// ListEvents retrieves audit events matching the filter with pagination.
+	ListEvents(ctx context.Context, filter *auditdto.AuditEventFilterRequest, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+}
+
+type AuditServiceDeps struct {
+	fx.In
+
+	AuditEventRepository auditmodel.AuditEventRepository
+}
+
+type auditService struct {
+	AuditServiceDeps
+}
+
+func NewAuditService(deps AuditServiceDeps) AuditService {
+	return &auditService{
+		AuditServiceDeps: deps,
+	}
+}
+
+func (a *auditService) Record(ctx context.Context, action, targetType, targetId string, before, after interface{}) error {
+	metadata := audit.MetadataFromContext(ctx)
+
+	beforeSnapshot, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterSnapshot, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	err = a.AuditEventRepository.Create(&auditmodel.AuditEvent{
+		EventId:        uuid.GenerateUUID(),
+		Actor:          metadata.Actor,
+		Action:         action,
+		TargetType:     targetType,
+		TargetId:       targetId,
+		RequestId:      metadata.RequestId,
+		ClientIp:       metadata.ClientIp,
+		BeforeSnapshot: beforeSnapshot,
+		AfterSnapshot:  afterSnapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("unexpected error recording audit event %s: %v", action, err)
+	}
+	return nil
+}
+
+func (a *auditService) ListEvents(ctx context.Context, filter *auditdto.AuditEventFilterRequest, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	events, pageEnv, err := a.AuditEventRepository.List(
+		auditdto.ModelFromAuditEventFilterRequest(filter),
+		paginationRequest.Limit,
+		paginationRequest.Page,
+	)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching audit events")
+	}
+
+	pageEnv.Data = auditdto.NewAuditEventListResponse(events)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func snapshot(v interface{}) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error building audit snapshot")
+	}
+	s := string(b)
+	return &s, nil
+}
@@ -3,13 +3,25 @@ package domain
 import (
 	"go.uber.org/fx"
 
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
 	"github.com/pedromspeixoto/users-api/internal/domain/health"
+	"github.com/pedromspeixoto/users-api/internal/domain/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/search"
+	"github.com/pedromspeixoto/users-api/internal/domain/shares"
+	"github.com/pedromspeixoto/users-api/internal/domain/storage"
 	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
 )
 
 func ProvideDomains() fx.Option {
 	return fx.Provide(
+		audit.NewAuditService,
 		health.NewHealthService,
+		jobs.NewJobService,
+		search.NewSearchService,
+		shares.NewShareService,
+		storage.NewFileStorage,
 		users.NewUserService,
+		webhooks.NewWebhookService,
 	)
 }
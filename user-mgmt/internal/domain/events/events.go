@@ -0,0 +1,44 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pedromspeixoto/users-api/internal/data/models/outbox"
+	"github.com/pedromspeixoto/users-api/internal/pkg/events"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+)
+
+const (
+	AggregateTypeUser = "user"
+	AggregateTypeFile = "file"
+)
+
+// NewOutboxEvent builds an outbox event with data as its json payload. It must be
+// stored in the same transaction as the change it describes.
+func NewOutboxEvent(eventType, aggregateType, aggregateId string, data interface{}) (*outbox.OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error building %s event payload: %v", eventType, err)
+	}
+
+	return &outbox.OutboxEvent{
+		EventId:       uuid.GenerateUUID(),
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Payload:       string(payload),
+	}, nil
+}
+
+// Envelope converts a stored outbox event into the envelope published to sinks.
+func Envelope(event *outbox.OutboxEvent) *events.Event {
+	return &events.Event{
+		Id:            event.EventId,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateId,
+		OccurredAt:    event.CreatedAt,
+		Data:          json.RawMessage(event.Payload),
+	}
+}
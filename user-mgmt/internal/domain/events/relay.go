@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/data/models/outbox"
+	"github.com/pedromspeixoto/users-api/internal/pkg/backoff"
+	"github.com/pedromspeixoto/users-api/internal/pkg/events"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+func InvokeRelay() fx.Option {
+	return fx.Invoke(NewRelay)
+}
+
+type relayDeps struct {
+	fx.In
+
+	LifeCycle        fx.Lifecycle
+	Config           *config.Config
+	Logger           *logger.LoggingClient
+	OutboxRepository outbox.OutboxRepository
+	Sinks            events.Sinks
+}
+
+// Relay polls the outbox and publishes pending events to every sink. An event is
+// only marked as published once all sinks accepted it, failed events are retried
+// with exponential backoff, so delivery is at-least-once. Events are claimed for a
+// lease so replicas don't relay the same ones, and an event that failed
+// OutboxMaxAttempts times is dead and no longer retried.
+type Relay struct {
+	relayDeps
+	logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRelay(deps relayDeps) *Relay {
+	relay := &Relay{
+		relayDeps: deps,
+		Logger:    deps.Logger.GetLogger(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	deps.LifeCycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			relay.Info("starting outbox relay")
+			go relay.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			relay.Info("stopping outbox relay")
+			close(relay.stop)
+			select {
+			case <-relay.done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return relay
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.Config.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.relayPending()
+		}
+	}
+}
+
+func (r *Relay) relayPending() {
+	pending, err := r.OutboxRepository.Claim(r.Config.OutboxBatchSize, r.Config.OutboxClaimLease)
+	if err != nil {
+		r.Errorf("failed to claim pending outbox events: %v", err)
+		return
+	}
+
+	for i := range pending {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		// another relay may have claimed the event once its lease expired
+		if !time.Now().Before(pending[i].NextAttemptAt) {
+			return
+		}
+		r.relay(&pending[i])
+	}
+}
+
+func (r *Relay) relay(event *outbox.OutboxEvent) {
+	err := r.publish(event)
+	if err != nil && event.Attempts+1 >= r.Config.OutboxMaxAttempts {
+		r.Errorf("failed to relay outbox event %s (attempt %d), giving up: %v", event.EventId, event.Attempts+1, err)
+		if err = r.OutboxRepository.MarkDead(event, err); err != nil {
+			r.Errorf("failed to record outbox event %s failure: %v", event.EventId, err)
+		}
+		return
+	}
+	if err != nil {
+		next := time.Now().Add(backoff.Exponential(event.Attempts+1, time.Second, r.Config.OutboxMaxBackoff))
+		r.Warningf("failed to relay outbox event %s (attempt %d), retrying at %s: %v", event.EventId, event.Attempts+1, next.Format(time.RFC3339), err)
+		if err = r.OutboxRepository.MarkFailed(event, err, next); err != nil {
+			r.Errorf("failed to record outbox event %s failure: %v", event.EventId, err)
+		}
+		return
+	}
+
+	if err = r.OutboxRepository.MarkPublished(event); err != nil {
+		// the event will be published again on the next poll
+		r.Errorf("failed to mark outbox event %s as published: %v", event.EventId, err)
+	}
+}
+
+func (r *Relay) publish(event *outbox.OutboxEvent) error {
+	envelope := Envelope(event)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, sink := range r.Sinks {
+		if err := sink.Publish(ctx, envelope); err != nil {
+			return fmt.Errorf("%s sink: %v", sink.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+
+	jobmodel "github.com/pedromspeixoto/users-api/internal/data/models/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	jobsdto "github.com/pedromspeixoto/users-api/internal/dto/jobs"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+// JobService provides methods pertaining to managing background jobs.
+type JobService interface {
+	// CreateFileJob queues the creation of a new user file, processed in the background
+	CreateFileJob(ctx context.Context, userId string, request *usersdto.UserFileRequest) (*jobsdto.JobResponse, error)
+	// GetJob retrieves a job by uuid
+	GetJob(ctx context.Context, uuid string) (*jobsdto.JobResponse, error)
+	// CancelJob cancels a queued job, or requests the cancellation of a running job
+	CancelJob(ctx context.Context, uuid string) (*jobsdto.JobResponse, error)
+}
+
+type JobServiceDeps struct {
+	fx.In
+
+	Logger        *logger.LoggingClient
+	JobRepository jobmodel.JobRepository
+	UserService   users.UserService
+}
+
+type jobService struct {
+	JobServiceDeps
+	logger.Logger
+}
+
+func NewJobService(deps JobServiceDeps) JobService {
+	return &jobService{
+		JobServiceDeps: deps,
+		Logger:         deps.Logger.GetLogger(),
+	}
+}
+
+func (j *jobService) CreateFileJob(ctx context.Context, userId string, request *usersdto.UserFileRequest) (*jobsdto.JobResponse, error) {
+	// check if user exists
+	_, err := j.UserService.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the worker acts on behalf of whoever queued the job
+	metadata := audit.MetadataFromContext(ctx)
+	model, err := jobsdto.ModelFromFileIngestRequest(userId, request, metadata.Actor, metadata.RequestId)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error encoding job request")
+	}
+	err = j.JobRepository.Create(model)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating new job")
+	}
+
+	return jobsdto.NewJobResponse(model), nil
+}
+
+func (j *jobService) GetJob(ctx context.Context, uuid string) (*jobsdto.JobResponse, error) {
+	job, err := j.JobRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeJobNotFound, "job %s not found", uuid)
+	}
+
+	return jobsdto.NewJobResponse(job), nil
+}
+
+func (j *jobService) CancelJob(ctx context.Context, uuid string) (*jobsdto.JobResponse, error) {
+	job, err := j.JobRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeJobNotFound, "job %s not found", uuid)
+	}
+
+	if job.Status != jobmodel.StatusQueued && job.Status != jobmodel.StatusRunning {
+		return nil, apperrors.Conflict(apperrors.CodeJobFinished, "job is already %s", job.Status)
+	}
+
+	err = j.JobRepository.Cancel(job)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error cancelling job")
+	}
+
+	return jobsdto.NewJobResponse(job), nil
+}
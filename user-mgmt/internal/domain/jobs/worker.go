@@ -0,0 +1,244 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	jobmodel "github.com/pedromspeixoto/users-api/internal/data/models/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	jobsdto "github.com/pedromspeixoto/users-api/internal/dto/jobs"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+func InvokeWorker() fx.Option {
+	return fx.Invoke(NewWorker)
+}
+
+type workerDeps struct {
+	fx.In
+
+	LifeCycle     fx.Lifecycle
+	Config        *config.Config
+	Logger        *logger.LoggingClient
+	JobRepository jobmodel.JobRepository
+	UserService   users.UserService
+}
+
+// Worker runs a pool of goroutines that claim queued jobs and process them. While
+// a job runs its lease is renewed together with its progress; jobs left behind by
+// a stopped or crashed instance are claimed again once their lease expires.
+type Worker struct {
+	workerDeps
+	logger.Logger
+
+	id   string
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewWorker(deps workerDeps) *Worker {
+	worker := &Worker{
+		workerDeps: deps,
+		Logger:     deps.Logger.GetLogger(),
+		id:         uuid.GenerateUUID(),
+		stop:       make(chan struct{}),
+	}
+
+	deps.LifeCycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			worker.Infof("starting %d job workers", deps.Config.JobWorkers)
+			for i := 0; i < deps.Config.JobWorkers; i++ {
+				worker.wg.Add(1)
+				go worker.run(fmt.Sprintf("%s/%d", worker.id, i))
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			worker.Info("stopping job workers")
+			close(worker.stop)
+
+			done := make(chan struct{})
+			go func() {
+				worker.wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return worker
+}
+
+func (w *Worker) run(owner string) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.Config.JobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.claimAndProcess(owner)
+		}
+	}
+}
+
+func (w *Worker) claimAndProcess(owner string) {
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		job, err := w.JobRepository.Claim(owner, w.Config.JobLeaseDuration)
+		if err != nil {
+			w.Errorf("failed to claim job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		w.process(job)
+	}
+}
+
+func (w *Worker) process(job *jobmodel.Job) {
+	// the file was stored by an attempt lost before it finished the job
+	if job.ResultId != nil {
+		w.finish(job, jobmodel.StatusSucceeded, nil)
+		return
+	}
+	if job.Attempts > w.Config.JobMaxAttempts {
+		w.finish(job, jobmodel.StatusFailed, apperrors.New(apperrors.KindInternal, apperrors.CodeJobAbandoned, "job abandoned after %d attempts", job.Attempts-1))
+		return
+	}
+
+	metadata := audit.Metadata{Actor: job.Actor}
+	if job.RequestId != nil {
+		metadata.RequestId = *job.RequestId
+	}
+	ctx, cancel := context.WithCancel(audit.WithMetadata(context.Background(), metadata))
+	defer cancel()
+
+	var progress int32
+	var cancelled, stopped atomic.Bool
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		w.heartbeat(ctx, job, &progress, func(requested bool) {
+			if requested {
+				cancelled.Store(true)
+			} else {
+				stopped.Store(true)
+			}
+			cancel()
+		})
+	}()
+
+	var err error
+	switch job.Type {
+	case jobmodel.TypeFileIngest:
+		var request *usersdto.UserFileRequest
+		request, err = jobsdto.FileIngestRequestFromModel(job)
+		if err != nil {
+			err = apperrors.Invalid(apperrors.CodeInvalidRequest, "invalid job payload: %v", err)
+			break
+		}
+
+		// the result is stored with the file, a later attempt does not store it again
+		var userFile *usersdto.UserFileResponse
+		userFile, err = w.UserService.IngestUserFile(ctx, job.UserId, request, func(percent int) {
+			atomic.StoreInt32(&progress, int32(percent))
+		}, func(tx *gorm.DB, fileId string) error {
+			return w.JobRepository.WithTx(tx).StoreResult(job, fileId)
+		})
+		if err == nil {
+			job.ResultId = &userFile.FileId
+		}
+	default:
+		err = apperrors.Invalid(apperrors.CodeInvalidRequest, "unknown job type %s", job.Type)
+	}
+
+	cancel()
+	<-heartbeatDone
+	job.Progress = int(atomic.LoadInt32(&progress))
+
+	switch {
+	case err == nil:
+		w.finish(job, jobmodel.StatusSucceeded, nil)
+	case cancelled.Load():
+		w.finish(job, jobmodel.StatusCancelled, nil)
+	case stopped.Load():
+		// the job is picked up again, by this or another instance
+		if err := w.JobRepository.Release(job); err != nil {
+			w.Errorf("failed to release job %s: %v", job.JobId, err)
+		}
+	default:
+		w.finish(job, jobmodel.StatusFailed, err)
+	}
+}
+
+// heartbeat renews the lease of job until ctx is done. abort is called when the
+// cancellation of the job is requested, or when the worker is stopping.
+func (w *Worker) heartbeat(ctx context.Context, job *jobmodel.Job, progress *int32, abort func(cancelRequested bool)) {
+	ticker := time.NewTicker(w.Config.JobLeaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			abort(false)
+			return
+		case <-ticker.C:
+			job.Progress = int(atomic.LoadInt32(progress))
+			cancelRequested, err := w.JobRepository.Heartbeat(job, w.Config.JobLeaseDuration)
+			if err != nil {
+				// the lease is kept until it expires, the next beat may succeed
+				w.Errorf("failed to renew lease of job %s: %v", job.JobId, err)
+				continue
+			}
+			if cancelRequested {
+				abort(true)
+				return
+			}
+		}
+	}
+}
+
+func (w *Worker) finish(job *jobmodel.Job, status string, cause error) {
+	job.Status = status
+	if status == jobmodel.StatusSucceeded {
+		job.Progress = 100
+	}
+	if cause != nil {
+		message := cause.Error()
+		code := apperrors.As(cause).Code
+		status := apperrors.HTTPStatus(cause)
+		job.Error = &message
+		job.Code = &code
+		job.ErrorCode = &status
+	}
+
+	if err := w.JobRepository.Finish(job); err != nil {
+		w.Errorf("failed to finish job %s: %v", job.JobId, err)
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	searchdto "github.com/pedromspeixoto/users-api/internal/dto/search"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+// SearchService provides methods pertaining to searching user files by content.
+type SearchService interface {
+	// SearchFiles retrieves the files whose text matches query with pagination. Callers
+	// only find their own files.
+	SearchFiles(ctx context.Context, query string, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+}
+
+type SearchServiceDeps struct {
+	fx.In
+
+	Config                 *config.Config
+	Logger                 *logger.LoggingClient
+	UserFileTextRepository usermodel.UserFileTextRepository
+}
+
+type searchService struct {
+	SearchServiceDeps
+	logger.Logger
+}
+
+func NewSearchService(deps SearchServiceDeps) SearchService {
+	return &searchService{
+		SearchServiceDeps: deps,
+		Logger:            deps.Logger.GetLogger(),
+	}
+}
+
+func (s *searchService) SearchFiles(ctx context.Context, query string, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, apperrors.Invalid(apperrors.CodeSearchQueryEmpty, "search query must not be empty")
+	}
+
+	actor := audit.MetadataFromContext(ctx).Actor
+	if actor == audit.AnonymousActor {
+		return nil, apperrors.New(apperrors.KindUnauthenticated, apperrors.CodeActorRequired, "searching files requires the %s header", audit.ActorHeader)
+	}
+
+	// the actor header is not authenticated, so it cannot grant access to the
+	// files of other users
+	results, pageEnv, err := s.UserFileTextRepository.Search(query, actor, paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error searching files")
+	}
+
+	highlighter := newHighlighter(query)
+	files := &searchdto.FileSearchListResponse{}
+	for i := range results {
+		snippets := highlighter.snippets(results[i].Text, maxSnippets)
+		files.Files = append(files.Files, *searchdto.NewFileSearchResponse(&results[i], snippets))
+	}
+
+	pageEnv.Data = files
+	return dto.NewPaginationResponse(pageEnv), nil
+}
@@ -0,0 +1,118 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxSnippets = 3
+	// snippetContext is the number of bytes of text kept around each match.
+	snippetContext = 80
+	// minTermLength is the InnoDB default for the shortest indexed word.
+	minTermLength = 3
+)
+
+// highlighter finds the terms of a search query in text and builds snippets around
+// them, with the terms wrapped in <em> tags and the rest of the text HTML escaped.
+type highlighter struct {
+	terms *regexp.Regexp
+}
+
+func newHighlighter(query string) *highlighter {
+	var terms []string
+	for _, term := range strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		// shorter terms are not indexed by MySQL either
+		if utf8.RuneCountInString(term) >= minTermLength {
+			terms = append(terms, regexp.QuoteMeta(term))
+		}
+	}
+	if len(terms) == 0 {
+		return &highlighter{}
+	}
+
+	// terms match at the start of words, the first group is the term itself
+	return &highlighter{terms: regexp.MustCompile(`(?i)(?:^|[^\pL\pN])(` + strings.Join(terms, "|") + `)`)}
+}
+
+func (h *highlighter) snippets(text string, limit int) []string {
+	snippets := []string{}
+	if h.terms == nil {
+		return snippets
+	}
+
+	end := 0
+	for _, match := range h.matches(text) {
+		if len(snippets) == limit {
+			break
+		}
+		// matches inside the previous snippet are already highlighted
+		if match[0] < end {
+			continue
+		}
+
+		start := runeStart(text, max(match[0]-snippetContext, end))
+		end = runeStart(text, min(match[1]+snippetContext, len(text)))
+		snippets = append(snippets, h.highlight(text, start, end))
+	}
+	return snippets
+}
+
+func (h *highlighter) highlight(text string, start, end int) string {
+	window := text[start:end]
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	last := 0
+	for _, match := range h.matches(window) {
+		snippet.WriteString(html.EscapeString(window[last:match[0]]))
+		snippet.WriteString("<em>")
+		snippet.WriteString(html.EscapeString(window[match[0]:match[1]]))
+		snippet.WriteString("</em>")
+		last = match[1]
+	}
+	snippet.WriteString(html.EscapeString(window[last:]))
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+
+	return strings.Join(strings.Fields(snippet.String()), " ")
+}
+
+// matches returns the start and end of every term found in text.
+func (h *highlighter) matches(text string) [][2]int {
+	var matches [][2]int
+	for _, match := range h.terms.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, [2]int{match[2], match[3]})
+	}
+	return matches
+}
+
+// runeStart moves i back to the start of the rune it falls in.
+func runeStart(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,232 @@
+package shares
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	sharemodel "github.com/pedromspeixoto/users-api/internal/data/models/shares"
+	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	sharesdto "github.com/pedromspeixoto/users-api/internal/dto/shares"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	pkgaudit "github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/tokens"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"go.uber.org/fx"
+)
+
+// ShareService provides methods pertaining to sharing user files through signed links.
+type ShareService interface {
+	// ShareUserFile creates an expiring, signed link to the content of a user file
+	ShareUserFile(ctx context.Context, userId string, fileId string, request *sharesdto.ShareRequest) (*sharesdto.ShareResponse, error)
+	// ListShares retrieves the shares of a user file with pagination, newest first
+	ListShares(ctx context.Context, userId string, fileId string, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+	// RevokeShare revokes a share, its link stops working right away
+	RevokeShare(ctx context.Context, userId string, fileId string, shareId string) (*sharesdto.ShareResponse, error)
+	// DownloadSharedFile downloads the current version of a shared file with the token
+	// of its link, counting the download
+	DownloadSharedFile(ctx context.Context, token string) (*usersdto.UserFileDownload, error)
+}
+
+type ShareServiceDeps struct {
+	fx.In
+
+	Config              *config.Config
+	Logger              *logger.LoggingClient
+	TokenSigner         *tokens.TokenSigner
+	FileShareRepository sharemodel.FileShareRepository
+	UserFileRepository  usermodel.UserFileRepository
+	UserService         users.UserService
+	AuditService        audit.AuditService
+}
+
+type shareService struct {
+	ShareServiceDeps
+	logger.Logger
+}
+
+func NewShareService(deps ShareServiceDeps) ShareService {
+	return &shareService{
+		ShareServiceDeps: deps,
+		Logger:           deps.Logger.GetLogger(),
+	}
+}
+
+func (s *shareService) ShareUserFile(ctx context.Context, userId string, fileId string, request *sharesdto.ShareRequest) (*sharesdto.ShareResponse, error) {
+	ttl := s.Config.ShareDefaultTTL
+	if request.ExpiresIn > 0 {
+		ttl = time.Duration(request.ExpiresIn) * time.Second
+	}
+	if s.Config.ShareMaxTTL > 0 && ttl > s.Config.ShareMaxTTL {
+		return nil, apperrors.Invalid(apperrors.CodeInvalidShareExpiration, "shares expire after %s at most", s.Config.ShareMaxTTL)
+	}
+
+	file, err := s.getFile(ctx, userId, fileId)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status == usermodel.FileStatusQuarantined {
+		return nil, apperrors.Forbidden(apperrors.CodeFileQuarantined, "user file %s is quarantined", fileId)
+	}
+
+	model := &sharemodel.FileShare{
+		ShareId:      uuid.GenerateUUID(),
+		FileId:       file.FileId,
+		UserId:       file.UserId,
+		CreatedBy:    pkgaudit.MetadataFromContext(ctx).Actor,
+		ExpiresAt:    time.Now().Add(ttl),
+		MaxDownloads: request.MaxDownloads,
+	}
+	token, err := s.TokenSigner.SignUntil(tokens.Claims{
+		Purpose: tokens.PurposeFileShare,
+		Subject: model.ShareId,
+	}, model.ExpiresAt)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error signing share link")
+	}
+
+	err = s.FileShareRepository.Create(model)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating new share")
+	}
+
+	response := sharesdto.NewShareResponse(model)
+	s.recordAudit(ctx, audit.ActionFileShare, file.FileId, nil, response)
+
+	// the link is only handed out once, it is never stored
+	response.Url = strings.TrimSuffix(s.Config.ShareUrl, "/") + "/" + token
+	return response, nil
+}
+
+func (s *shareService) ListShares(ctx context.Context, userId string, fileId string, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	file, err := s.getFile(ctx, userId, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, pageEnv, err := s.FileShareRepository.List(file.FileId, paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching shares")
+	}
+
+	pageEnv.Data = sharesdto.NewShareListResponse(shares)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func (s *shareService) RevokeShare(ctx context.Context, userId string, fileId string, shareId string) (*sharesdto.ShareResponse, error) {
+	file, err := s.getFile(ctx, userId, fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := s.FileShareRepository.GetByUUID(shareId)
+	if err != nil || share.FileId != file.FileId {
+		return nil, apperrors.NotFound(apperrors.CodeShareNotFound, "share %s not found", shareId)
+	}
+	if share.RevokedAt != nil {
+		return nil, apperrors.Conflict(apperrors.CodeShareRevoked, "share %s is already revoked", shareId)
+	}
+
+	before := sharesdto.NewShareResponse(share)
+	err = s.FileShareRepository.Revoke(share)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error revoking share")
+	}
+
+	response := sharesdto.NewShareResponse(share)
+	s.recordAudit(ctx, audit.ActionFileShareRevoke, file.FileId, before, response)
+
+	return response, nil
+}
+
+func (s *shareService) DownloadSharedFile(ctx context.Context, token string) (*usersdto.UserFileDownload, error) {
+	claims, err := s.TokenSigner.Verify(token, tokens.PurposeFileShare)
+	if errors.Is(err, tokens.ErrExpiredToken) {
+		return nil, apperrors.Gone(apperrors.CodeShareExpired, "share link has expired")
+	}
+	if err != nil {
+		return nil, apperrors.Forbidden(apperrors.CodeShareInvalid, "invalid share link: %v", err)
+	}
+
+	share, err := s.FileShareRepository.GetByUUID(claims.Subject)
+	if err != nil {
+		return nil, apperrors.NotFound(apperrors.CodeShareNotFound, "share %s not found", claims.Subject)
+	}
+
+	file, err := s.UserFileRepository.GetByUUID(share.FileId)
+	if err != nil || file.DeletedAt.Valid || file.Expired(time.Now()) {
+		return nil, apperrors.Gone(apperrors.CodeFileDeleted, "shared file is no longer available")
+	}
+
+	if err = unavailable(share, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// downloads through a link are recorded on behalf of the share
+	metadata := pkgaudit.MetadataFromContext(ctx)
+	metadata.Actor = "share:" + share.ShareId
+	download, err := s.UserService.DownloadUserFile(pkgaudit.WithMetadata(ctx, metadata), share.UserId, share.FileId)
+	if err != nil {
+		return nil, err
+	}
+
+	// only downloads whose content could be fetched use up the share
+	counted, err := s.FileShareRepository.CountDownload(share.ShareId)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error counting share download")
+	}
+	if !counted {
+		// the share changed since it was read, by a concurrent download or a revocation
+		if share, err = s.FileShareRepository.GetByUUID(share.ShareId); err == nil {
+			if err = unavailable(share, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		return nil, apperrors.Gone(apperrors.CodeShareExhausted, "share has no download left")
+	}
+	return download, nil
+}
+
+// unavailable returns why share can no longer be downloaded at now, or nil when it can.
+func unavailable(share *sharemodel.FileShare, now time.Time) error {
+	switch {
+	case share.RevokedAt != nil:
+		return apperrors.Gone(apperrors.CodeShareRevoked, "share has been revoked")
+	case !now.Before(share.ExpiresAt):
+		return apperrors.Gone(apperrors.CodeShareExpired, "share link has expired")
+	case share.MaxDownloads != nil && share.DownloadCount >= *share.MaxDownloads:
+		return apperrors.Gone(apperrors.CodeShareExhausted, "share has no download left")
+	}
+	return nil
+}
+
+// getFile retrieves a user file that is not deleted, and that belongs to the user.
+func (s *shareService) getFile(ctx context.Context, userId string, fileId string) (*usermodel.UserFile, error) {
+	// check if user exists
+	_, err := s.UserService.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.UserFileRepository.GetByUUID(fileId)
+	if err != nil || file.UserId != userId {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", fileId)
+	}
+	if file.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeFileDeleted, "user file %s is deleted", fileId)
+	}
+	return file, nil
+}
+
+func (s *shareService) recordAudit(ctx context.Context, action, fileId string, before, after interface{}) {
+	if err := s.AuditService.Record(ctx, action, audit.TargetTypeFile, fileId, before, after); err != nil {
+		s.Errorf("failed to record audit event %s for file %s: %v", action, fileId, err)
+	}
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	storagemodel "github.com/pedromspeixoto/users-api/internal/data/models/storage"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// rotationBatchSize is the number of blobs re-wrapped per query during a key rotation.
+const rotationBatchSize = 100
+
+// FileStorage stores file content addressed by its SHA-256 digest. Identical content
+// is stored once and reference counted, so it is only removed with its last reference.
+// Content is encrypted at rest with a data key per blob, itself wrapped by a master
+// key, and decrypted transparently when read.
+type FileStorage interface {
+	// Put stores content as part of the transaction tx, or adds a reference to the
+	// identical content already stored, and returns its hex encoded digest.
+	Put(tx *gorm.DB, content []byte) (string, error)
+	// Get retrieves the content with the given digest.
+	Get(ctx context.Context, digest string) ([]byte, error)
+	// Release removes a reference to the content with the given digest as part of
+	// the transaction tx. Cached thumbnails go with the last reference.
+	Release(tx *gorm.DB, digest string) error
+
+	// GetThumbnail retrieves the cached thumbnail of a size for the content with the
+	// given digest, returning gorm.ErrRecordNotFound when it was not generated yet.
+	GetThumbnail(ctx context.Context, digest string, size int) ([]byte, error)
+	// PutThumbnail caches the thumbnail of a size for the content with the given digest.
+	PutThumbnail(ctx context.Context, digest string, size int, thumbnail []byte) error
+
+	// RotateKeys wraps the data key of every blob with the active master key, without
+	// rewriting their content, and encrypts blobs stored before encryption. Thumbnails
+	// of other keys are dropped, they are generated again when requested.
+	RotateKeys(ctx context.Context) (*RotationResult, error)
+}
+
+// RotationResult sums up a key rotation.
+type RotationResult struct {
+	KeyId             string
+	Rewrapped         int
+	Encrypted         int
+	ThumbnailsDropped int64
+}
+
+type FileStorageDeps struct {
+	fx.In
+
+	Logger                  *logger.LoggingClient
+	KeyProvider             encryption.KeyProvider
+	FileBlobRepository      storagemodel.FileBlobRepository
+	FileThumbnailRepository storagemodel.FileThumbnailRepository
+}
+
+type fileStorage struct {
+	FileStorageDeps
+	logger.Logger
+}
+
+func NewFileStorage(deps FileStorageDeps) FileStorage {
+	return &fileStorage{
+		FileStorageDeps: deps,
+		Logger:          deps.Logger.GetLogger(),
+	}
+}
+
+// Digest returns the hex encoded SHA-256 digest of content.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *fileStorage) Put(tx *gorm.DB, content []byte) (string, error) {
+	digest := Digest(content)
+	envelope, err := encryption.Seal(s.KeyProvider, content)
+	if err != nil {
+		return "", fmt.Errorf("could not encrypt content: %v", err)
+	}
+	err = s.FileBlobRepository.WithTx(tx).Acquire(&storagemodel.FileBlob{
+		Sha256:     digest,
+		Size:       int64(len(content)),
+		Content:    envelope.Ciphertext,
+		KeyId:      &envelope.KeyId,
+		WrappedKey: envelope.WrappedKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+func (s *fileStorage) Get(ctx context.Context, digest string) ([]byte, error) {
+	blob, err := s.FileBlobRepository.GetBySha256(digest)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(blob.Content, blob.KeyId, blob.WrappedKey)
+}
+
+func (s *fileStorage) Release(tx *gorm.DB, digest string) error {
+	deleted, err := s.FileBlobRepository.WithTx(tx).Release(digest)
+	if err != nil || !deleted {
+		return err
+	}
+	return s.FileThumbnailRepository.WithTx(tx).DeleteBySha256(digest)
+}
+
+func (s *fileStorage) GetThumbnail(ctx context.Context, digest string, size int) ([]byte, error) {
+	thumbnail, err := s.FileThumbnailRepository.Get(digest, size)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(thumbnail.Content, thumbnail.KeyId, thumbnail.WrappedKey)
+}
+
+func (s *fileStorage) PutThumbnail(ctx context.Context, digest string, size int, thumbnail []byte) error {
+	envelope, err := encryption.Seal(s.KeyProvider, thumbnail)
+	if err != nil {
+		return fmt.Errorf("could not encrypt thumbnail: %v", err)
+	}
+	return s.FileThumbnailRepository.Create(&storagemodel.FileThumbnail{
+		Sha256:      digest,
+		Size:        size,
+		ContentType: files.ThumbnailContentType,
+		Content:     envelope.Ciphertext,
+		KeyId:       &envelope.KeyId,
+		WrappedKey:  envelope.WrappedKey,
+	})
+}
+
+func (s *fileStorage) RotateKeys(ctx context.Context) (*RotationResult, error) {
+	result := &RotationResult{KeyId: s.KeyProvider.ActiveKeyId()}
+
+	var afterId uint
+	for {
+		blobs, err := s.FileBlobRepository.ListNotWrappedBy(result.KeyId, afterId, rotationBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(blobs) == 0 {
+			break
+		}
+
+		for i := range blobs {
+			if err = ctx.Err(); err != nil {
+				return result, err
+			}
+			blob := &blobs[i]
+			afterId = blob.ID
+
+			encrypted := blob.KeyId == nil
+			stored, err := s.rotateBlob(blob)
+			if err != nil {
+				return result, fmt.Errorf("could not rotate the key of blob %s: %v", blob.Sha256, err)
+			}
+			switch {
+			case !stored:
+				// released or rotated concurrently, nothing left to do
+			case encrypted:
+				result.Encrypted++
+			default:
+				result.Rewrapped++
+			}
+		}
+	}
+
+	dropped, err := s.FileThumbnailRepository.DeleteNotWrappedBy(result.KeyId)
+	if err != nil {
+		return result, err
+	}
+	result.ThumbnailsDropped = dropped
+	return result, nil
+}
+
+// rotateBlob wraps the data key of blob with the active master key, encrypting its
+// content first when it is stored in plaintext.
+func (s *fileStorage) rotateBlob(blob *storagemodel.FileBlob) (bool, error) {
+	previousKeyId := blob.KeyId
+	if previousKeyId == nil {
+		stored, err := s.FileBlobRepository.GetBySha256(blob.Sha256)
+		if err != nil {
+			return false, err
+		}
+		if stored.KeyId != nil {
+			return false, nil
+		}
+		envelope, err := encryption.Seal(s.KeyProvider, stored.Content)
+		if err != nil {
+			return false, err
+		}
+		blob.Content = envelope.Ciphertext
+		blob.KeyId = &envelope.KeyId
+		blob.WrappedKey = envelope.WrappedKey
+		return s.FileBlobRepository.UpdateKey(blob, previousKeyId)
+	}
+
+	keyId, wrapped, err := encryption.Rewrap(s.KeyProvider, *previousKeyId, blob.WrappedKey)
+	if err != nil {
+		return false, err
+	}
+	blob.KeyId = &keyId
+	blob.WrappedKey = wrapped
+	return s.FileBlobRepository.UpdateKey(blob, previousKeyId)
+}
+
+// open decrypts content stored with its data key wrapped by the master key keyId,
+// content without a key is stored in plaintext.
+func (s *fileStorage) open(content []byte, keyId *string, wrappedKey []byte) ([]byte, error) {
+	if keyId == nil {
+		return content, nil
+	}
+	return encryption.Open(s.KeyProvider, &encryption.Envelope{
+		KeyId:      *keyId,
+		WrappedKey: wrappedKey,
+		Ciphertext: content,
+	})
+}
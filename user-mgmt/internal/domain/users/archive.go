@@ -0,0 +1,129 @@
+package users
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+)
+
+const (
+	// archivePageSize is the number of files loaded at once while archiving.
+	archivePageSize = 50
+	// archiveManifestName is the name of the manifest in archives.
+	archiveManifestName = "manifest.json"
+)
+
+func (u *userService) ArchiveUserFiles(ctx context.Context, userId string, filter *usersdto.UserFileFilterRequest) (*usersdto.UserFileArchive, error) {
+	// check if user exists
+	_, err := u.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usersdto.UserFileArchive{
+		Filename: fmt.Sprintf("%s-files.zip", userId),
+		Write: func(w io.Writer) error {
+			if err := u.writeArchive(ctx, userId, usersdto.ModelFromUserFileFilterRequest(filter), w); err != nil {
+				return err
+			}
+			u.recordAudit(ctx, audit.ActionFileArchive, audit.TargetTypeUser, userId, nil, filter)
+			return nil
+		},
+	}, nil
+}
+
+// writeArchive writes the files of a user matching filter to w as a ZIP archive,
+// one page of files and one file content at a time. The manifest is written last.
+func (u *userService) writeArchive(ctx context.Context, userId string, filter *users.UserFileFilter, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	manifest := &usersdto.UserFileArchiveManifest{
+		UserId:    userId,
+		CreatedAt: time.Now().UTC(),
+		Files:     []usersdto.UserFileArchiveEntry{},
+	}
+
+	// files are paged by id, files created or deleted meanwhile do not shift the pages
+	var afterId uint
+	for {
+		userFiles, err := u.UserFileRepository.ListAfter(userId, filter, afterId, archivePageSize)
+		if err != nil {
+			return apperrors.Internal(err, "unexpected error fetching user files")
+		}
+
+		for i := range userFiles {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			entry, err := u.archiveFile(ctx, archive, &userFiles[i])
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, *entry)
+			afterId = userFiles[i].ID
+		}
+
+		if len(userFiles) < archivePageSize {
+			break
+		}
+	}
+
+	writer, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     archiveManifestName,
+		Method:   zip.Deflate,
+		Modified: manifest.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// archiveFile adds the content of file to archive and returns its manifest entry.
+func (u *userService) archiveFile(ctx context.Context, archive *zip.Writer, file *users.UserFile) (*usersdto.UserFileArchiveEntry, error) {
+	entry := &usersdto.UserFileArchiveEntry{
+		UserFileResponse: usersdto.NewUserFileResponse(file),
+	}
+	if file.Status == users.FileStatusQuarantined {
+		return entry, nil
+	}
+
+	content, err := u.FileStorage.Get(ctx, file.Sha256)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error fetching content of user file %s: %v", file.FileId, err)
+	}
+
+	// content that is compressed already is only stored
+	method := zip.Deflate
+	switch files.MediaType(file.FileType) {
+	case files.MimeTypePNG, files.MimeTypeJPEG, files.MimeTypeGIF, files.MimeTypeZIP:
+		method = zip.Store
+	}
+
+	entry.Path = fmt.Sprintf("files/%s.%s", file.FileId, fileExtension(file.FileType))
+	writer, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     entry.Path,
+		Method:   method,
+		Modified: file.UpdatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(content); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
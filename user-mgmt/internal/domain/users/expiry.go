@@ -0,0 +1,195 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/events"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	pkgaudit "github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	pkgevents "github.com/pedromspeixoto/users-api/internal/pkg/events"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// ExpiryActor is the audit actor of files deleted by the expiry sweeper.
+const ExpiryActor = "system:expiry"
+
+var (
+	filesExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "users_api_files_expired_total",
+		Help: "Number of user files deleted by the expiry sweeper.",
+	})
+	expirySweepFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "users_api_file_expiry_sweep_failures_total",
+		Help: "Number of expiry sweeps that failed.",
+	})
+)
+
+func (u *userService) ExpireUserFiles(ctx context.Context, after *users.ExpiryCursor, limit int) (int, *users.ExpiryCursor, error) {
+	now := time.Now()
+	expired, err := u.UserFileRepository.ListExpired(now, after, limit)
+	if err != nil {
+		return 0, nil, apperrors.Internal(err, "unexpected error fetching expired user files")
+	}
+
+	ctx = pkgaudit.WithMetadata(ctx, pkgaudit.Metadata{Actor: ExpiryActor})
+	count := 0
+	for i := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+
+		file := &expired[i]
+		deleted := false
+		err = u.Db.Transaction(func(tx *gorm.DB) error {
+			// another instance may have swept the file, or its expiry was changed
+			var err error
+			if deleted, err = u.UserFileRepository.WithTx(tx).Expire(file, now); err != nil || !deleted {
+				return err
+			}
+			return u.publishEvent(tx, pkgevents.TypeFileDeleted, events.AggregateTypeFile, file.FileId, &fileEventData{
+				UserId:           file.UserId,
+				UserFileResponse: usersdto.NewUserFileResponse(file),
+			})
+		})
+		if err != nil {
+			u.Logger.Errorf("failed to expire user file %s: %v", file.FileId, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+
+		u.recordAudit(ctx, audit.ActionFileExpire, audit.TargetTypeFile, file.FileId, usersdto.NewUserFileResponse(file), nil)
+		count++
+	}
+
+	// files that failed to expire are passed over, they are retried on the next sweep
+	if len(expired) < limit {
+		return count, nil, nil
+	}
+	last := expired[len(expired)-1]
+	return count, &users.ExpiryCursor{ExpiresAt: *last.ExpiresAt, ID: last.ID}, nil
+}
+
+// defaultExpiry returns when a new file of fileType expires with the retention
+// configured for its type, nil when it is kept forever.
+func (u *userService) defaultExpiry(fileType string) *time.Time {
+	mediaType := files.MediaType(fileType)
+	for retainedType, retention := range u.Config.FileRetention {
+		if files.MediaType(retainedType) != mediaType || retention <= 0 {
+			continue
+		}
+		expiresAt := time.Now().Add(retention)
+		return &expiresAt
+	}
+	return nil
+}
+
+func InvokeExpirySweeper() fx.Option {
+	return fx.Invoke(NewExpirySweeper)
+}
+
+type expirySweeperDeps struct {
+	fx.In
+
+	LifeCycle   fx.Lifecycle
+	Config      *config.Config
+	Logger      *logger.LoggingClient
+	UserService UserService
+}
+
+// ExpirySweeper periodically soft deletes the user files whose expiry passed. They
+// stay restorable, and are purged like any other deleted file.
+type ExpirySweeper struct {
+	expirySweeperDeps
+	logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewExpirySweeper(deps expirySweeperDeps) *ExpirySweeper {
+	sweeper := &ExpirySweeper{
+		expirySweeperDeps: deps,
+		Logger:            deps.Logger.GetLogger(),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+
+	deps.LifeCycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			sweeper.Info("starting file expiry sweeper")
+			go sweeper.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			sweeper.Info("stopping file expiry sweeper")
+			close(sweeper.stop)
+			select {
+			case <-sweeper.done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return sweeper
+}
+
+func (s *ExpirySweeper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.Config.FileExpirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// sweep expires batches of files until none is left, passing over the files that
+// failed to expire.
+func (s *ExpirySweeper) sweep() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var after *users.ExpiryCursor
+	for ctx.Err() == nil {
+		count, next, err := s.UserService.ExpireUserFiles(ctx, after, s.Config.FileExpirySweepBatchSize)
+		if err != nil {
+			expirySweepFailures.Inc()
+			s.Errorf("failed to sweep expired user files: %v", err)
+			return
+		}
+		filesExpired.Add(float64(count))
+		if count > 0 {
+			s.Infof("expired %d user files", count)
+		}
+		if next == nil {
+			return
+		}
+		after = next
+	}
+}
@@ -2,55 +2,124 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/pedromspeixoto/users-api/internal/data/models/outbox"
 	"github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
 	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+	"github.com/pedromspeixoto/users-api/internal/pkg/mailer"
+	"github.com/pedromspeixoto/users-api/internal/pkg/scanner"
+	"github.com/pedromspeixoto/users-api/internal/pkg/tokens"
 	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/events"
+	"github.com/pedromspeixoto/users-api/internal/domain/storage"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
 	"github.com/pedromspeixoto/users-api/internal/dto"
 	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	pkgevents "github.com/pedromspeixoto/users-api/internal/pkg/events"
 	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 )
 
-const (
-	PdfFileType = "application/pdf"
-)
+// ErrQuotaExceeded is returned when storing a file would exceed the quotas of its user.
+var ErrQuotaExceeded = errors.New("user storage quota exceeded")
 
-// UserService provides methods pertaining to managing users.
+// UserService provides methods pertaining to managing users. Its methods fail with
+// an *apperrors.Error, so that every transport can report it the same way.
 type UserService interface {
 	// CreateUser creates a new user
-	CreateUser(ctx context.Context, Post *usersdto.UserRequest) (int, *usersdto.UserResponse, error)
+	CreateUser(ctx context.Context, Post *usersdto.UserRequest) (*usersdto.UserResponse, error)
 	// ListUsers retrieves all users with pagination.
-	ListUsers(ctx context.Context, pagination *dto.PaginationRequest) (int, *dto.PaginationResponse, error)
+	ListUsers(ctx context.Context, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
 	// GetUser retrieves a user by uuid
-	GetUser(ctx context.Context, uuid string) (int, *usersdto.UserResponse, error)
+	GetUser(ctx context.Context, uuid string) (*usersdto.UserResponse, error)
 	// DeleteUser soft deletes a user entry by uuid
-	DeleteUser(ctx context.Context, uuid string) (int, error)
+	DeleteUser(ctx context.Context, uuid string) error
+	// RestoreUser restores a soft deleted user entry by uuid
+	RestoreUser(ctx context.Context, uuid string) (*usersdto.UserResponse, error)
+	// VerifyUser marks the user email as verified using a token sent by email
+	VerifyUser(ctx context.Context, uuid string, request *usersdto.VerifyUserRequest) (*usersdto.UserResponse, error)
 
-	// CreateUserFile creates a new user file
-	CreateUserFile(ctx context.Context, userId string) (int, *usersdto.UserFileResponse, error)
-	// ListUserFiles retrieves all user files with pagination.
-	ListUserFiles(ctx context.Context, userId string, pagination *dto.PaginationRequest) (int, *dto.PaginationResponse, error)
+	// CreateUserFile creates a new user file, fetched from the file serving service
+	// or imported from the requested url
+	CreateUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest) (*usersdto.UserFileResponse, error)
+	// IngestUserFile fetches, validates and stores a new user file like CreateUserFile,
+	// reporting the completed percentage to progress after each step. Unless nil,
+	// stored is called with the id of the file as part of the transaction storing it
+	IngestUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest, progress func(percent int), stored func(tx *gorm.DB, fileId string) error) (*usersdto.UserFileResponse, error)
+	// UploadUserFile creates a new user file with content sent by the client, declared
+	// as fileType which is sniffed when empty. The url of request must be empty
+	UploadUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest, fileType string, content []byte) (*usersdto.UserFileResponse, error)
+	// ListUserFiles retrieves all user files matching the filter with pagination.
+	ListUserFiles(ctx context.Context, userId string, filter *usersdto.UserFileFilterRequest, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
 	// GetUserFile retrieves a user file by uuid
-	GetUserFile(ctx context.Context, userId string, uuid string) (int, *usersdto.UserFileResponse, error)
+	GetUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileResponse, error)
+	// UpdateUserFile changes the filename, description and tags of a user file by uuid
+	UpdateUserFile(ctx context.Context, userId string, uuid string, request *usersdto.UserFileUpdateRequest) (*usersdto.UserFileResponse, error)
 	// DeleteUserFile soft deletes a user file entry by uuid
-	DeleteUserFile(ctx context.Context, userId string, uuid string) (int, error)
+	DeleteUserFile(ctx context.Context, userId string, uuid string) error
+	// RestoreUserFile restores a soft deleted user file entry by uuid
+	RestoreUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileResponse, error)
+	// PurgeUserFile permanently deletes a user file entry by uuid with its versions,
+	// their content is removed once no other file shares it
+	PurgeUserFile(ctx context.Context, userId string, uuid string) error
 	// DownloadUserFile download a user file by uuid
-	DownloadUserFile(ctx context.Context, userId string, uuid string) (int, []byte, string, error)
+	DownloadUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileDownload, error)
+	// GetUserFileThumbnail retrieves a PNG preview of a user file, generated on first use
+	GetUserFileThumbnail(ctx context.Context, userId string, uuid string, size int) ([]byte, error)
+	// CreateUserFileVersion uploads a new version of a user file, fetched like
+	// CreateUserFile, and makes it the current one
+	CreateUserFileVersion(ctx context.Context, userId string, uuid string, request *usersdto.UserFileRequest) (*usersdto.UserFileResponse, error)
+	// ListUserFileVersions retrieves the versions of a user file with pagination, newest first
+	ListUserFileVersions(ctx context.Context, userId string, uuid string, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+	// DownloadUserFileVersion downloads a version of a user file
+	DownloadUserFileVersion(ctx context.Context, userId string, uuid string, version int) (*usersdto.UserFileDownload, error)
+	// RestoreUserFileVersion makes an older version of a user file the current one,
+	// by storing a copy of it as a new version
+	RestoreUserFileVersion(ctx context.Context, userId string, uuid string, version int) (*usersdto.UserFileResponse, error)
+	// ArchiveUserFiles prepares a ZIP archive of the user files matching the filter,
+	// with a manifest of their metadata, generated while it is written
+	ArchiveUserFiles(ctx context.Context, userId string, filter *usersdto.UserFileFilterRequest) (*usersdto.UserFileArchive, error)
+	// ExpireUserFiles soft deletes up to limit user files whose expiry passed, after
+	// the given cursor when not nil. It returns how many were deleted and the cursor
+	// of the next batch, nil once no expired file is left
+	ExpireUserFiles(ctx context.Context, after *users.ExpiryCursor, limit int) (int, *users.ExpiryCursor, error)
+	// GetUserUsage retrieves the storage used by a user and its quotas
+	GetUserUsage(ctx context.Context, userId string) (*usersdto.UserUsageResponse, error)
 }
 
 type UserServiceDeps struct {
 	fx.In
 
-	Config             *config.Config
-	Logger             *logger.LoggingClient
-	UserRepository     users.UserRepository
-	UserFileRepository users.UserFileRepository
-	FileServingClient  *files.FileServingClient
+	Config                    *config.Config
+	Logger                    *logger.LoggingClient
+	Db                        *gorm.DB
+	UserRepository            users.UserRepository
+	UserFileRepository        users.UserFileRepository
+	UserFileTextRepository    users.UserFileTextRepository
+	UserFileVersionRepository users.UserFileVersionRepository
+	FileServingClient         *files.FileServingClient
+	URLImporter               *files.URLImporter
+	Scanner                   scanner.Scanner
+	FileStorage               storage.FileStorage
+	Mailer                    mailer.Mailer
+	TokenSigner               *tokens.TokenSigner
+	OutboxRepository          outbox.OutboxRepository
+	AuditService              audit.AuditService
+	WebhookService            webhooks.WebhookService
+}
+
+// fileEventData is the payload of file events.
+type fileEventData struct {
+	UserId string `json:"user_id"`
+	*usersdto.UserFileResponse
 }
 
 type userService struct {
@@ -65,148 +134,902 @@ func NewUserService(deps UserServiceDeps) UserService {
 	}
 }
 
-func (u *userService) CreateUser(ctx context.Context, request *usersdto.UserRequest) (int, *usersdto.UserResponse, error) {
+func (u *userService) CreateUser(ctx context.Context, request *usersdto.UserRequest) (*usersdto.UserResponse, error) {
 	model := usersdto.ModelFromUserRequest(request)
-	err := u.UserRepository.Create(model)
+	response := usersdto.NewUserResponse(model)
+	err := u.Db.Transaction(func(tx *gorm.DB) error {
+		if err := u.UserRepository.WithTx(tx).Create(model); err != nil {
+			return err
+		}
+		return u.publishEvent(tx, pkgevents.TypeUserCreated, events.AggregateTypeUser, model.UserId, response)
+	})
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating new user")
+	}
+
+	u.recordAudit(ctx, audit.ActionUserCreate, audit.TargetTypeUser, model.UserId, nil, response)
+
+	// a failed or slow delivery should not fail the user creation
+	mailCtx, cancel := context.WithTimeout(ctx, u.Config.MailerTimeout)
+	defer cancel()
+	err = u.sendVerificationEmail(mailCtx, model)
+	if err != nil {
+		u.Logger.Errorf("failed to send verification email to user %s: %v", model.UserId, err)
+	}
+
+	return response, nil
+}
+
+// publishEvent stores a domain event in the outbox and enqueues its webhook
+// deliveries as part of the transaction tx.
+func (u *userService) publishEvent(tx *gorm.DB, eventType, aggregateType, aggregateId string, data interface{}) error {
+	event, err := events.NewOutboxEvent(eventType, aggregateType, aggregateId, data)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("unexpected error creating new user: %v", err)
+		return err
+	}
+	if err = u.OutboxRepository.WithTx(tx).Create(event); err != nil {
+		return err
 	}
+	return u.WebhookService.EnqueueDeliveries(tx, event)
+}
 
-	return http.StatusCreated, usersdto.NewUserResponse(model), nil
+// recordAudit records an audit event, a failure to do so is logged but does not
+// fail the audited operation.
+func (u *userService) recordAudit(ctx context.Context, action, targetType, targetId string, before, after interface{}) {
+	err := u.AuditService.Record(ctx, action, targetType, targetId, before, after)
+	if err != nil {
+		u.Logger.Errorf("failed to record audit event %s for %s %s: %v", action, targetType, targetId, err)
+	}
 }
 
-func (u *userService) ListUsers(ctx context.Context, paginationRequest *dto.PaginationRequest) (int, *dto.PaginationResponse, error) {
+func (u *userService) sendVerificationEmail(ctx context.Context, user *users.User) error {
+	token, err := u.TokenSigner.Sign(tokens.Claims{
+		Purpose: tokens.PurposeEmailVerification,
+		Subject: user.UserId,
+		Email:   user.Email,
+	})
+	if err != nil {
+		return apperrors.Internal(err, "unexpected error signing verification token")
+	}
+
+	return u.Mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Please verify your email address by sending the token below to POST %s/%s:verify\n\n%s\n\nThe token expires in %s.",
+			u.Config.VerificationUrl,
+			user.UserId,
+			token,
+			u.Config.VerificationTokenTTL,
+		),
+	})
+}
+
+func (u *userService) ListUsers(ctx context.Context, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
 	users, pageEnv, err := u.UserRepository.List(paginationRequest.Limit, paginationRequest.Page)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("unexpdected error fetching users: %v", err)
+		return nil, apperrors.Internal(err, "unexpected error fetching users")
 	}
 
 	pageEnv.Data = usersdto.NewUserListResponse(users)
-	return http.StatusOK, dto.NewPaginationResponse(pageEnv), nil
+	return dto.NewPaginationResponse(pageEnv), nil
 }
 
-func (u *userService) GetUser(ctx context.Context, uuid string) (int, *usersdto.UserResponse, error) {
+func (u *userService) GetUser(ctx context.Context, uuid string) (*usersdto.UserResponse, error) {
 	user, err := u.UserRepository.GetByUUID(uuid)
 	if err != nil {
-		return http.StatusNotFound, nil, err
+		return nil, apperrors.Lookup(err, apperrors.CodeUserNotFound, "user %s not found", uuid)
 	}
 
-	return http.StatusOK, usersdto.NewUserResponse(user), nil
+	return usersdto.NewUserResponse(user), nil
 }
 
-func (u *userService) DeleteUser(ctx context.Context, uuid string) (int, error) {
+func (u *userService) DeleteUser(ctx context.Context, uuid string) error {
 	post, err := u.UserRepository.GetByUUID(uuid)
 	if err != nil {
-		return http.StatusNotFound, err
+		return apperrors.Lookup(err, apperrors.CodeUserNotFound, "user %s not found", uuid)
+	}
+
+	err = u.Db.Transaction(func(tx *gorm.DB) error {
+		if err := u.UserRepository.WithTx(tx).SoftDelete(post); err != nil {
+			return err
+		}
+		return u.publishEvent(tx, pkgevents.TypeUserDeleted, events.AggregateTypeUser, post.UserId, usersdto.NewUserResponse(post))
+	})
+	if err != nil {
+		return apperrors.Internal(err, "unexpected error deleting user")
+	}
+
+	u.recordAudit(ctx, audit.ActionUserDelete, audit.TargetTypeUser, post.UserId, usersdto.NewUserResponse(post), nil)
+
+	return nil
+}
+
+func (u *userService) RestoreUser(ctx context.Context, uuid string) (*usersdto.UserResponse, error) {
+	user, err := u.UserRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeUserNotFound, "user %s not found", uuid)
+	}
+	if !user.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeUserNotDeleted, "user %s is not deleted", uuid)
+	}
+
+	err = u.UserRepository.Restore(user)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error restoring user")
+	}
+
+	response := usersdto.NewUserResponse(user)
+	u.recordAudit(ctx, audit.ActionUserRestore, audit.TargetTypeUser, user.UserId, nil, response)
+
+	return response, nil
+}
+
+func (u *userService) VerifyUser(ctx context.Context, uuid string, request *usersdto.VerifyUserRequest) (*usersdto.UserResponse, error) {
+	user, err := u.UserRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeUserNotFound, "user %s not found", uuid)
 	}
 
-	err = u.UserRepository.SoftDelete(post)
+	claims, err := u.TokenSigner.Verify(request.Token, tokens.PurposeEmailVerification)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("unexpected error deleting user: %v", err)
+		return nil, apperrors.Invalid(apperrors.CodeInvalidVerifyToken, "invalid verification token: %v", err)
 	}
+	if claims.Subject != user.UserId || claims.Email != user.Email {
+		return nil, apperrors.Invalid(apperrors.CodeInvalidVerifyToken, "invalid verification token: token was not issued for this user")
+	}
+
+	// verifying an already verified user is a no-op
+	if user.EmailVerifiedAt != nil {
+		return usersdto.NewUserResponse(user), nil
+	}
+
+	before := usersdto.NewUserResponse(user)
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
+	err = u.UserRepository.Update(user)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error verifying user")
+	}
+
+	response := usersdto.NewUserResponse(user)
+	u.recordAudit(ctx, audit.ActionUserVerify, audit.TargetTypeUser, user.UserId, before, response)
+
+	return response, nil
+}
 
-	return http.StatusOK, nil
+func (u *userService) CreateUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest) (*usersdto.UserFileResponse, error) {
+	return u.IngestUserFile(ctx, userId, request, nil, nil)
 }
 
-func (u *userService) CreateUserFile(ctx context.Context, userId string) (int, *usersdto.UserFileResponse, error) {
+func (u *userService) IngestUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest, progress func(percent int), stored func(tx *gorm.DB, fileId string) error) (*usersdto.UserFileResponse, error) {
+	report := func(percent int) {
+		if progress != nil {
+			progress(percent)
+		}
+	}
+
 	// check if user exists
-	code, user, err := u.GetUser(ctx, userId)
+	user, err := u.GetUser(ctx, userId)
 	if err != nil {
-		return code, nil, err
+		return nil, err
+	}
+	if err = validateFileRequest(request); err != nil {
+		return nil, err
 	}
 
-	fileContent, fileType, err := u.FileServingClient.GetRandomFile()
+	upload, err := u.fetchUpload(ctx, request, report)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("unexpected error fetching file from client: %v", err)
+		return nil, err
 	}
+	report(75)
+
+	response, err := u.createUserFile(ctx, user, request, upload, stored)
+	if err != nil {
+		return nil, err
+	}
+	report(100)
+
+	return response, nil
+}
 
-	if strings.Contains(fileType, PdfFileType) {
-		err = files.CheckPDFCorrupted(fileContent)
+func (u *userService) UploadUserFile(ctx context.Context, userId string, request *usersdto.UserFileRequest, fileType string, content []byte) (*usersdto.UserFileResponse, error) {
+	// check if user exists
+	user, err := u.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if request != nil && request.Url != "" {
+		return nil, apperrors.Invalid(apperrors.CodeInvalidRequest, "url cannot be set when the content is uploaded")
+	}
+	if err = validateFileRequest(request); err != nil {
+		return nil, err
+	}
+
+	upload, err := u.prepareUpload(ctx, content, fileType)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.createUserFile(ctx, user, request, upload, nil)
+}
+
+// validateFileRequest checks the parts of a file request the validator cannot.
+func validateFileRequest(request *usersdto.UserFileRequest) error {
+	if request != nil && request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return apperrors.Invalid(apperrors.CodeInvalidExpiry, "expires_at must be in the future")
+	}
+	return nil
+}
+
+// createUserFile stores an upload as the first version of a new file of user,
+// described by request, calling stored as part of the same transaction unless nil.
+func (u *userService) createUserFile(ctx context.Context, user *usersdto.UserResponse, request *usersdto.UserFileRequest, upload *upload, stored func(tx *gorm.DB, fileId string) error) (*usersdto.UserFileResponse, error) {
+	// last chance to give up before the file is stored
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.New(apperrors.KindUnavailable, apperrors.CodeRequestAborted, "user file creation aborted: %v", err)
+	}
+
+	model := &users.UserFile{
+		UserId:    user.UserId,
+		FileId:    uuid.GenerateUUID(),
+		ExpiresAt: u.defaultExpiry(upload.fileType),
+	}
+	if request != nil {
+		filename := request.Filename
+		if filename == "" {
+			filename = files.FilenameFromURL(request.Url)
+		}
+		model.Filename = optionalFilename(filename)
+		model.Description = optionalString(request.Description)
+		model.Tags = normalizeTags(request.Tags)
+		if request.ExpiresAt != nil {
+			model.ExpiresAt = request.ExpiresAt
+		}
+	}
+
+	var response *usersdto.UserFileResponse
+	err := u.Db.Transaction(func(tx *gorm.DB) error {
+		if err := u.reserveQuota(tx, model.UserId, 1, upload.size()); err != nil {
+			return err
+		}
+		if err := u.storeVersion(tx, model, upload); err != nil {
+			return err
+		}
+		if err := u.UserFileRepository.WithTx(tx).Create(model); err != nil {
+			return err
+		}
+		if stored != nil {
+			if err := stored(tx, model.FileId); err != nil {
+				return err
+			}
+		}
+		response = usersdto.NewUserFileResponse(model)
+		return u.publishEvent(tx, pkgevents.TypeFileCreated, events.AggregateTypeFile, model.FileId, &fileEventData{
+			UserId:           model.UserId,
+			UserFileResponse: response,
+		})
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, apperrors.New(apperrors.KindQuotaExceeded, apperrors.CodeQuotaExceeded, "%v", err)
+	}
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating new user file")
+	}
+
+	u.recordAudit(ctx, audit.ActionFileCreate, audit.TargetTypeFile, model.FileId, nil, response)
+	u.uploadStored(ctx, model, upload, response)
+
+	return response, nil
+}
+
+// upload is the content of a new file or version, validated and ready to be stored.
+type upload struct {
+	content  []byte
+	fileType string
+	scan     *scanner.Result
+	metadata *users.UserFileMetadata
+	text     string
+}
+
+func (up *upload) size() int64 {
+	return int64(len(up.content))
+}
+
+// fetchUpload fetches the content of a new file or version from the file serving
+// service, or imports it from the requested url, then prepares it.
+func (u *userService) fetchUpload(ctx context.Context, request *usersdto.UserFileRequest, report func(percent int)) (*upload, error) {
+	var fileContent []byte
+	var fileType string
+	var err error
+	if request != nil && request.Url != "" {
+		fileContent, fileType, err = u.URLImporter.Import(ctx, request.Url)
+		if err != nil {
+			return nil, importError(err)
+		}
+	} else {
+		fileContent, fileType, err = u.FileServingClient.GetRandomFile(ctx)
 		if err != nil {
-			return http.StatusBadRequest, nil, fmt.Errorf("file possibly corrupted. could not open file: %v", err)
+			return nil, upstreamError(err)
 		}
 	}
+	report(50)
 
-	model := &users.UserFile{
-		UserId:      user.UserId,
-		FileId:      uuid.GenerateUUID(),
-		FileType:    fileType,
-		FileContent: fileContent,
+	return u.prepareUpload(ctx, fileContent, fileType)
+}
+
+// prepareUpload validates, scans and inspects the content of a new file or version,
+// declared as fileType.
+func (u *userService) prepareUpload(ctx context.Context, fileContent []byte, fileType string) (*upload, error) {
+	if u.Config.FileMaxSize > 0 && int64(len(fileContent)) > u.Config.FileMaxSize {
+		return nil, apperrors.New(apperrors.KindTooLarge, apperrors.CodeFileTooLarge, "%v: %d bytes, the maximum is %d", files.ErrFileTooLarge, len(fileContent), u.Config.FileMaxSize)
 	}
 
-	err = u.UserFileRepository.Create(model)
+	// the declared type is only trusted when it agrees with the content
+	fileType, err := files.ValidateContent(fileType, fileContent)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("unexpected error creating new user file: %v", err)
+		if errors.Is(err, files.ErrContentTypeMismatch) {
+			return nil, apperrors.New(apperrors.KindUnsupportedMediaType, apperrors.CodeFileTypeMismatch, "%v", err)
+		}
+		return nil, apperrors.Invalid(apperrors.CodeFileCorrupted, "file possibly corrupted. could not open file: %v", err)
+	}
+	if !u.allowedFileType(fileType) {
+		return nil, apperrors.New(apperrors.KindUnsupportedMediaType, apperrors.CodeFileTypeNotAllowed, "file type %s is not allowed", fileType)
+	}
+
+	scan, err := u.Scanner.Scan(ctx, fileContent)
+	if err != nil {
+		if !u.Config.ScannerFailOpen {
+			return nil, apperrors.Wrap(apperrors.KindUnavailable, apperrors.CodeScannerUnavailable, err, "could not scan file for malware")
+		}
+		u.Warningf("storing file that could not be scanned by %s: %v", u.Scanner.Name(), err)
+		scan = &scanner.Result{}
+	}
+
+	upload := &upload{
+		content:  fileContent,
+		fileType: fileType,
+		scan:     scan,
+	}
+	if err = u.inspect(upload); err != nil {
+		return nil, apperrors.Invalid(apperrors.CodeFileCorrupted, "file possibly corrupted. could not read metadata: %v", err)
+	}
+	return upload, nil
+}
+
+// inspect extracts the metadata and searchable text of an upload. Infected content
+// is kept for investigation, but never read again.
+func (u *userService) inspect(upload *upload) error {
+	if upload.scan.Infected || files.MediaType(upload.fileType) != files.MimeTypePDF {
+		return nil
+	}
+	var err error
+	upload.metadata, upload.text, err = u.extractPDF(upload.content)
+	return err
+}
+
+// storeVersion stores an upload as the next version of file as part of the
+// transaction tx, and makes it the current one. The caller saves file.
+func (u *userService) storeVersion(tx *gorm.DB, file *users.UserFile, upload *upload) error {
+	digest, err := u.FileStorage.Put(tx, upload.content)
+	if err != nil {
+		return err
+	}
+
+	file.Version++
+	file.FileType = upload.fileType
+	file.FileSize = upload.size()
+	file.Sha256 = digest
+	file.Status = users.FileStatusAvailable
+	file.QuarantineReason = nil
+	file.Metadata = upload.metadata
+	if upload.scan.Infected {
+		file.Status = users.FileStatusQuarantined
+		file.QuarantineReason = &upload.scan.Signature
 	}
 
-	return http.StatusCreated, usersdto.NewUserFileResponse(model), nil
+	err = u.UserFileVersionRepository.WithTx(tx).Create(&users.UserFileVersion{
+		FileId:           file.FileId,
+		Version:          file.Version,
+		FileType:         file.FileType,
+		FileSize:         file.FileSize,
+		Sha256:           file.Sha256,
+		Status:           file.Status,
+		QuarantineReason: file.QuarantineReason,
+	})
+	if err != nil {
+		return err
+	}
+
+	// only the current version can be found
+	textRepository := u.UserFileTextRepository.WithTx(tx)
+	if err := textRepository.DeleteByFileUUID(file.FileId); err != nil {
+		return err
+	}
+	if upload.text == "" {
+		return nil
+	}
+	return textRepository.Create(&users.UserFileText{
+		FileId:  file.FileId,
+		UserId:  file.UserId,
+		Content: upload.text,
+	})
 }
 
-func (u *userService) ListUserFiles(ctx context.Context, userId string, paginationRequest *dto.PaginationRequest) (int, *dto.PaginationResponse, error) {
+// uploadStored reports infected uploads and pre-generates the thumbnail of clean ones.
+func (u *userService) uploadStored(ctx context.Context, file *users.UserFile, upload *upload, response *usersdto.UserFileResponse) {
+	if upload.scan.Infected {
+		u.Warningf("user file %s quarantined, %s detected %s", file.FileId, u.Scanner.Name(), upload.scan.Signature)
+		u.recordAudit(ctx, audit.ActionFileQuarantine, audit.TargetTypeFile, file.FileId, nil, response)
+		return
+	}
+
+	// images get their default preview right away, other types on first request
+	switch files.MediaType(file.FileType) {
+	case files.MimeTypePNG, files.MimeTypeJPEG:
+		if _, err := u.thumbnail(ctx, file, upload.content, u.Config.ThumbnailDefaultSize); err != nil {
+			u.Warningf("failed to generate thumbnail of user file %s: %v", file.FileId, err)
+		}
+	}
+}
+
+func (u *userService) ListUserFiles(ctx context.Context, userId string, filter *usersdto.UserFileFilterRequest, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
 	// check if user exists
-	code, _, err := u.GetUser(ctx, userId)
+	_, err := u.GetUser(ctx, userId)
 	if err != nil {
-		return code, nil, err
+		return nil, err
 	}
 
-	files, pageEnv, err := u.UserFileRepository.List(userId, paginationRequest.Limit, paginationRequest.Page)
+	files, pageEnv, err := u.UserFileRepository.List(userId, usersdto.ModelFromUserFileFilterRequest(filter), paginationRequest.Limit, paginationRequest.Page)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("unexpdected error fetching user files: %v", err)
+		return nil, apperrors.Internal(err, "unexpected error fetching user files")
 	}
 
 	pageEnv.Data = usersdto.NewUserFileListResponse(files)
-	return http.StatusOK, dto.NewPaginationResponse(pageEnv), nil
+	return dto.NewPaginationResponse(pageEnv), nil
 }
 
-func (u *userService) GetUserFile(ctx context.Context, userId string, fileId string) (int, *usersdto.UserFileResponse, error) {
+// getUserFile retrieves a file of a user, deleted or not. The files of other users
+// are not found.
+func (u *userService) getUserFile(ctx context.Context, userId string, uuid string) (*users.UserFile, error) {
 	// check if user exists
-	code, _, err := u.GetUser(ctx, userId)
+	_, err := u.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := u.UserFileRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeFileNotFound, "user file %s not found", uuid)
+	}
+	if file.UserId != userId {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", uuid)
+	}
+	return file, nil
+}
+
+func (u *userService) GetUserFile(ctx context.Context, userId string, fileId string) (*usersdto.UserFileResponse, error) {
+	file, err := u.getUserFile(ctx, userId, fileId)
+	if err != nil {
+		return nil, err
+	}
+	// an expired file is only kept until the sweeper deletes it
+	if file.Expired(time.Now()) {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", fileId)
+	}
+
+	return usersdto.NewUserFileResponse(file), nil
+}
+
+func (u *userService) UpdateUserFile(ctx context.Context, userId string, uuid string, request *usersdto.UserFileUpdateRequest) (*usersdto.UserFileResponse, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if file.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeFileDeleted, "user file %s is deleted", uuid)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Invalid(apperrors.CodeInvalidExpiry, "expires_at must be in the future")
+	}
+	before := usersdto.NewUserFileResponse(file)
+
+	if request.Filename != nil {
+		file.Filename = optionalFilename(*request.Filename)
+	}
+	if request.Description != nil {
+		file.Description = optionalString(*request.Description)
+	}
+	if request.Tags != nil {
+		file.Tags = normalizeTags(*request.Tags)
+	}
+	if request.ExpiresAt != nil {
+		file.ExpiresAt = request.ExpiresAt
+	}
+	if request.NoExpiry {
+		file.ExpiresAt = nil
+	}
+
+	var response *usersdto.UserFileResponse
+	err = u.Db.Transaction(func(tx *gorm.DB) error {
+		if err := u.UserFileRepository.WithTx(tx).UpdateDetails(file); err != nil {
+			return err
+		}
+		response = usersdto.NewUserFileResponse(file)
+		return u.publishEvent(tx, pkgevents.TypeFileUpdated, events.AggregateTypeFile, file.FileId, &fileEventData{
+			UserId:           file.UserId,
+			UserFileResponse: response,
+		})
+	})
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error updating user file")
+	}
+
+	u.recordAudit(ctx, audit.ActionFileUpdate, audit.TargetTypeFile, file.FileId, before, response)
+	return response, nil
+}
+
+func (u *userService) DeleteUserFile(ctx context.Context, userId string, uuid string) error {
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return err
+	}
+
+	err = u.Db.Transaction(func(tx *gorm.DB) error {
+		if err := u.UserFileRepository.WithTx(tx).SoftDelete(file); err != nil {
+			return err
+		}
+		return u.publishEvent(tx, pkgevents.TypeFileDeleted, events.AggregateTypeFile, file.FileId, &fileEventData{
+			UserId:           file.UserId,
+			UserFileResponse: usersdto.NewUserFileResponse(file),
+		})
+	})
+	if err != nil {
+		return apperrors.Internal(err, "unexpected error deleting user file")
+	}
+
+	u.recordAudit(ctx, audit.ActionFileDelete, audit.TargetTypeFile, file.FileId, usersdto.NewUserFileResponse(file), nil)
+
+	return nil
+}
+
+func (u *userService) RestoreUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileResponse, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
 	if err != nil {
-		return code, nil, err
+		return nil, err
+	}
+	if !file.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeFileNotDeleted, "user file %s is not deleted", uuid)
+	}
+	// the sweeper would delete an expired file again right away
+	if file.Expired(time.Now()) {
+		file.ExpiresAt = nil
 	}
 
-	file, err := u.UserFileRepository.GetByUUID(fileId)
+	err = u.Db.Transaction(func(tx *gorm.DB) error {
+		size, err := u.UserFileVersionRepository.WithTx(tx).Size(file.FileId)
+		if err != nil {
+			return err
+		}
+		if err := u.reserveQuota(tx, file.UserId, 1, size); err != nil {
+			return err
+		}
+		return u.UserFileRepository.WithTx(tx).Restore(file)
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, apperrors.New(apperrors.KindQuotaExceeded, apperrors.CodeQuotaExceeded, "%v", err)
+	}
 	if err != nil {
-		return http.StatusNotFound, nil, err
+		return nil, apperrors.Internal(err, "unexpected error restoring user file")
 	}
 
-	return http.StatusOK, usersdto.NewUserFileResponse(file), nil
+	response := usersdto.NewUserFileResponse(file)
+	u.recordAudit(ctx, audit.ActionFileRestore, audit.TargetTypeFile, file.FileId, nil, response)
+
+	return response, nil
 }
 
-func (u *userService) DeleteUserFile(ctx context.Context, userId string, uuid string) (int, error) {
+func (u *userService) PurgeUserFile(ctx context.Context, userId string, uuid string) error {
 	// check if user exists
-	code, _, err := u.GetUser(ctx, userId)
+	_, err := u.GetUser(ctx, userId)
 	if err != nil {
-		return code, err
+		return err
 	}
 
-	file, err := u.UserFileRepository.GetByUUID(uuid)
+	var file *users.UserFile
+	err = u.Db.Transaction(func(tx *gorm.DB) error {
+		// purging is irreversible, the file is locked so that it is purged only once
+		file, err = u.UserFileRepository.WithTx(tx).GetUnscopedForUpdate(uuid)
+		if err != nil {
+			return err
+		}
+		if file.UserId != userId {
+			return gorm.ErrRecordNotFound
+		}
+		if err := u.UserFileRepository.WithTx(tx).HardDelete(file); err != nil {
+			return err
+		}
+		if err := u.UserFileTextRepository.WithTx(tx).DeleteByFileUUID(file.FileId); err != nil {
+			return err
+		}
+		versions, err := u.UserFileVersionRepository.WithTx(tx).DeleteByFileUUID(file.FileId)
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			if err := u.FileStorage.Release(tx, version.Sha256); err != nil {
+				return err
+			}
+		}
+		// a soft deleted file already announced its deletion
+		if file.DeletedAt.Valid {
+			return nil
+		}
+		return u.publishEvent(tx, pkgevents.TypeFileDeleted, events.AggregateTypeFile, file.FileId, &fileEventData{
+			UserId:           file.UserId,
+			UserFileResponse: usersdto.NewUserFileResponse(file),
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", uuid)
+	}
 	if err != nil {
-		return http.StatusNotFound, err
+		return apperrors.Internal(err, "unexpected error purging user file")
 	}
 
-	err = u.UserFileRepository.SoftDelete(file)
+	u.recordAudit(ctx, audit.ActionFilePurge, audit.TargetTypeFile, file.FileId, usersdto.NewUserFileResponse(file), nil)
+
+	return nil
+}
+
+func (u *userService) DownloadUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileDownload, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("unexpected error deleting user file: %v", err)
+		return nil, err
+	}
+	// an expired file is only kept until the sweeper deletes it
+	if file.Expired(time.Now()) {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", uuid)
 	}
 
-	return http.StatusOK, nil
+	return u.download(ctx, file, file.FileType, file.Sha256, file.Status)
 }
 
-func (u *userService) DownloadUserFile(ctx context.Context, userId string, uuid string) (int, []byte, string, error) {
+// download retrieves the content of a user file, or of one of its versions, named
+// after the file.
+func (u *userService) download(ctx context.Context, file *users.UserFile, fileType, sha256, status string) (*usersdto.UserFileDownload, error) {
+	if status == users.FileStatusQuarantined {
+		return nil, apperrors.Forbidden(apperrors.CodeFileQuarantined, "user file %s is quarantined", file.FileId)
+	}
+
+	content, err := u.FileStorage.Get(ctx, sha256)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching user file content")
+	}
+
+	u.recordAudit(ctx, audit.ActionFileDownload, audit.TargetTypeFile, file.FileId, nil, nil)
+
+	filename := fmt.Sprintf("%s.%s", file.FileId, fileExtension(fileType))
+	if file.Filename != nil {
+		filename = *file.Filename
+	}
+	return &usersdto.UserFileDownload{
+		Content:  content,
+		FileType: fileType,
+		Filename: filename,
+		Sha256:   sha256,
+	}, nil
+}
+
+func (u *userService) GetUserFileThumbnail(ctx context.Context, userId string, uuid string, size int) ([]byte, error) {
+	if !u.allowedThumbnailSize(size) {
+		return nil, apperrors.Invalid(apperrors.CodeInvalidThumbnailSize, "thumbnail size must be one of %v", u.Config.ThumbnailSizes)
+	}
+
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if file.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeFileDeleted, "user file %s is deleted", uuid)
+	}
+	// an expired file is only kept until the sweeper deletes it
+	if file.Expired(time.Now()) {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", uuid)
+	}
+
+	if file.Status == users.FileStatusQuarantined {
+		return nil, apperrors.Forbidden(apperrors.CodeFileQuarantined, "user file %s is quarantined", uuid)
+	}
+
+	thumbnail, err := u.FileStorage.GetThumbnail(ctx, file.Sha256, size)
+	if err == nil {
+		return thumbnail, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.Internal(err, "unexpected error fetching thumbnail")
+	}
+
+	content, err := u.FileStorage.Get(ctx, file.Sha256)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching user file content")
+	}
+
+	thumbnail, err = u.thumbnail(ctx, file, content, size)
+	if errors.Is(err, files.ErrThumbnailUnsupported) {
+		return nil, apperrors.New(apperrors.KindUnsupportedMediaType, apperrors.CodeThumbnailUnsupported, "%v", err)
+	}
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error generating thumbnail")
+	}
+
+	return thumbnail, nil
+}
+
+// extractPDF extracts the metadata and searchable text of a PDF. A document without
+// extractable text is still stored, it just cannot be found.
+func (u *userService) extractPDF(content []byte) (*users.UserFileMetadata, string, error) {
+	pdfMetadata, err := files.ExtractPDFMetadata(content)
+	if err != nil {
+		return nil, "", err
+	}
+	metadata := &users.UserFileMetadata{
+		PageCount:    pdfMetadata.PageCount,
+		Title:        pdfMetadata.Title,
+		Author:       pdfMetadata.Author,
+		PdfCreatedAt: pdfMetadata.CreatedAt,
+		Encrypted:    pdfMetadata.Encrypted,
+		PdfVersion:   pdfMetadata.Version,
+	}
+
+	text, err := files.ExtractPDFText(content, u.Config.SearchMaxTextSize)
+	if err != nil {
+		u.Warningf("failed to extract text from file: %v", err)
+	}
+	text = strings.TrimSpace(strings.Join([]string{pdfMetadata.Title, pdfMetadata.Author, text}, "\n"))
+
+	return metadata, text, nil
+}
+
+// thumbnail generates the thumbnail of a size for file and caches it. A failure to
+// cache is only logged, the thumbnail is generated again next time.
+func (u *userService) thumbnail(ctx context.Context, file *users.UserFile, content []byte, size int) ([]byte, error) {
+	thumbnail, err := files.Thumbnail(content, file.FileType, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.FileStorage.PutThumbnail(ctx, file.Sha256, size, thumbnail); err != nil {
+		u.Errorf("failed to cache thumbnail of user file %s: %v", file.FileId, err)
+	}
+	return thumbnail, nil
+}
+
+func (u *userService) allowedThumbnailSize(size int) bool {
+	for _, allowed := range u.Config.ThumbnailSizes {
+		if size == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *userService) GetUserUsage(ctx context.Context, userId string) (*usersdto.UserUsageResponse, error) {
 	// check if user exists
-	code, _, err := u.GetUser(ctx, userId)
+	_, err := u.GetUser(ctx, userId)
 	if err != nil {
-		return code, nil, "", err
+		return nil, err
 	}
 
-	file, err := u.UserFileRepository.GetByUUID(uuid)
+	fileCount, usedBytes, err := u.UserFileRepository.Usage(userId)
 	if err != nil {
-		return http.StatusNotFound, nil, "", err
+		return nil, apperrors.Internal(err, "unexpected error fetching user usage")
+	}
+
+	return &usersdto.UserUsageResponse{
+		UserId:      userId,
+		FileCount:   fileCount,
+		MaxFiles:    u.Config.UserQuotaFiles,
+		UsedBytes:   usedBytes,
+		MaxBytes:    u.Config.UserQuotaBytes,
+		MaxFileSize: u.Config.FileMaxSize,
+	}, nil
+}
+
+// reserveQuota checks that fileCount more files and size more bytes fit in the
+// quotas of a user as part of the transaction tx. The user row stays locked until
+// tx ends, so concurrent uploads of the same user cannot both pass the check.
+func (u *userService) reserveQuota(tx *gorm.DB, userId string, fileCount, size int64) error {
+	if u.Config.UserQuotaFiles <= 0 && u.Config.UserQuotaBytes <= 0 {
+		return nil
+	}
+
+	if err := u.UserRepository.WithTx(tx).Lock(userId); err != nil {
+		return err
+	}
+	usedFiles, usedBytes, err := u.UserFileRepository.WithTx(tx).Usage(userId)
+	if err != nil {
+		return err
+	}
+
+	if u.Config.UserQuotaFiles > 0 && usedFiles+fileCount > u.Config.UserQuotaFiles {
+		return fmt.Errorf("%w: the user already has %d of %d files", ErrQuotaExceeded, usedFiles, u.Config.UserQuotaFiles)
+	}
+	if u.Config.UserQuotaBytes > 0 && usedBytes+size > u.Config.UserQuotaBytes {
+		return fmt.Errorf("%w: %d of %d bytes used, the file needs %d", ErrQuotaExceeded, usedBytes, u.Config.UserQuotaBytes, size)
+	}
+	return nil
+}
+
+// allowedFileType reports whether files of fileType may be stored, any type is
+// allowed when no list is configured.
+func (u *userService) allowedFileType(fileType string) bool {
+	if len(u.Config.FileAllowedTypes) == 0 {
+		return true
 	}
+	mediaType := files.MediaType(fileType)
+	for _, allowed := range u.Config.FileAllowedTypes {
+		if files.MediaType(allowed) == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExtension returns the extension of files of fileType.
+func fileExtension(fileType string) string {
+	subtype := strings.Split(files.MediaType(fileType), "/")
+	return subtype[len(subtype)-1]
+}
 
-	// get file extension from file type
-	fileExtension := strings.Split(file.FileType, "/")[1]
+// optionalFilename sanitizes name, an empty result means the file has no name.
+func optionalFilename(name string) *string {
+	return optionalString(files.SanitizeFilename(name))
+}
+
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
 
-	return http.StatusOK, file.FileContent, fileExtension, nil
+// normalizeTags trims and lowercases tags, dropping empty and duplicate ones.
+func normalizeTags(names []string) []users.UserFileTag {
+	tags := make([]users.UserFileTag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, users.UserFileTag{Tag: name})
+	}
+	return tags
+}
+
+// upstreamError classifies the errors of fetching a file from the file server.
+func upstreamError(err error) error {
+	switch {
+	case errors.Is(err, files.ErrFileTooLarge):
+		return apperrors.New(apperrors.KindTooLarge, apperrors.CodeFileTooLarge, "could not fetch file: %v", err)
+	case errors.Is(err, files.ErrUpstreamUnavailable):
+		return apperrors.New(apperrors.KindUnavailable, apperrors.CodeFileServerUnavailable, "could not fetch file: %v", err)
+	case errors.Is(err, files.ErrUpstreamTimeout):
+		return apperrors.New(apperrors.KindUpstreamTimeout, apperrors.CodeFileServerTimeout, "could not fetch file: %v", err)
+	}
+	return apperrors.New(apperrors.KindUpstream, apperrors.CodeFileServerFailure, "could not fetch file: %v", err)
+}
+
+// importError classifies the errors of importing a file from a url.
+func importError(err error) error {
+	switch {
+	case errors.Is(err, files.ErrURLNotAllowed), errors.Is(err, files.ErrAddressBlocked), errors.Is(err, files.ErrTooManyRedirects):
+		return apperrors.Invalid(apperrors.CodeFileImportNotAllowed, "could not import file from url: %v", err)
+	case errors.Is(err, files.ErrFileTooLarge):
+		return apperrors.New(apperrors.KindTooLarge, apperrors.CodeFileTooLarge, "could not import file from url: %v", err)
+	}
+	return upstreamError(err)
 }
@@ -0,0 +1,199 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/events"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	pkgevents "github.com/pedromspeixoto/users-api/internal/pkg/events"
+	"github.com/pedromspeixoto/users-api/internal/pkg/scanner"
+	"gorm.io/gorm"
+)
+
+func (u *userService) CreateUserFileVersion(ctx context.Context, userId string, uuid string, request *usersdto.UserFileRequest) (*usersdto.UserFileResponse, error) {
+	file, err := u.getVersionedFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := u.fetchUpload(ctx, request, func(int) {})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := u.addVersion(ctx, file, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	u.recordAudit(ctx, audit.ActionFileVersionCreate, audit.TargetTypeFile, file.FileId, usersdto.NewUserFileResponse(file), response)
+	return response, nil
+}
+
+func (u *userService) ListUserFileVersions(ctx context.Context, userId string, uuid string, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, pageEnv, err := u.UserFileVersionRepository.List(file.FileId, paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching user file versions")
+	}
+
+	pageEnv.Data = usersdto.NewUserFileVersionListResponse(versions, file.Version)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func (u *userService) DownloadUserFileVersion(ctx context.Context, userId string, uuid string, version int) (*usersdto.UserFileDownload, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+	// an expired file is only kept until the sweeper deletes it
+	if file.Expired(time.Now()) {
+		return nil, apperrors.NotFound(apperrors.CodeFileNotFound, "user file %s not found", uuid)
+	}
+
+	fileVersion, err := u.UserFileVersionRepository.Get(uuid, version)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeVersionNotFound, "version %d of user file %s not found", version, uuid)
+	}
+
+	return u.download(ctx, file, fileVersion.FileType, fileVersion.Sha256, fileVersion.Status)
+}
+
+func (u *userService) RestoreUserFileVersion(ctx context.Context, userId string, uuid string, version int) (*usersdto.UserFileResponse, error) {
+	file, err := u.getVersionedFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	fileVersion, err := u.UserFileVersionRepository.Get(uuid, version)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeVersionNotFound, "version %d of user file %s not found", version, uuid)
+	}
+	if fileVersion.Version == file.Version {
+		return nil, apperrors.Conflict(apperrors.CodeVersionAlreadyCurrent, "version %d is already the current version of user file %s", version, uuid)
+	}
+	if fileVersion.Status == users.FileStatusQuarantined {
+		return nil, apperrors.Forbidden(apperrors.CodeVersionQuarantined, "version %d of user file %s is quarantined", version, uuid)
+	}
+
+	content, err := u.FileStorage.Get(ctx, fileVersion.Sha256)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching user file content")
+	}
+
+	// the content passed the checks when it was uploaded, only its metadata is needed
+	upload := &upload{
+		content:  content,
+		fileType: fileVersion.FileType,
+		scan:     &scanner.Result{},
+	}
+	if err = u.inspect(upload); err != nil {
+		return nil, apperrors.Internal(err, "unexpected error reading user file metadata")
+	}
+
+	response, err := u.addVersion(ctx, file, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	u.recordAudit(ctx, audit.ActionFileVersionRestore, audit.TargetTypeFile, file.FileId, usersdto.NewUserFileResponse(file), response)
+	return response, nil
+}
+
+// getVersionedFile retrieves a user file that new versions can be added to.
+func (u *userService) getVersionedFile(ctx context.Context, userId string, uuid string) (*users.UserFile, error) {
+	file, err := u.getUserFile(ctx, userId, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if file.DeletedAt.Valid {
+		return nil, apperrors.Conflict(apperrors.CodeFileDeleted, "user file %s is deleted", uuid)
+	}
+	return file, nil
+}
+
+// addVersion stores an upload as the new current version of file, and deletes the
+// versions beyond the configured maximum.
+func (u *userService) addVersion(ctx context.Context, file *users.UserFile, upload *upload) (*usersdto.UserFileResponse, error) {
+	// last chance to give up before the version is stored
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.New(apperrors.KindUnavailable, apperrors.CodeRequestAborted, "user file version creation aborted: %v", err)
+	}
+
+	var current *users.UserFile
+	var response *usersdto.UserFileResponse
+	err := u.Db.Transaction(func(tx *gorm.DB) error {
+		// the user is locked before the file, as reserveQuota locks it
+		if err := u.UserRepository.WithTx(tx).Lock(file.UserId); err != nil {
+			return err
+		}
+
+		// versions of the same file are numbered one after the other
+		var err error
+		current, err = u.UserFileRepository.WithTx(tx).GetForUpdate(file.FileId)
+		if err != nil {
+			return err
+		}
+		// the versions pushed out by the new one no longer count towards the quota
+		if err = u.pruneVersions(tx, current.FileId, current.Version+1); err != nil {
+			return err
+		}
+		if err = u.reserveQuota(tx, file.UserId, 0, upload.size()); err != nil {
+			return err
+		}
+		if err = u.storeVersion(tx, current, upload); err != nil {
+			return err
+		}
+		if err = u.UserFileRepository.WithTx(tx).UpdateVersion(current); err != nil {
+			return err
+		}
+
+		response = usersdto.NewUserFileResponse(current)
+		return u.publishEvent(tx, pkgevents.TypeFileUpdated, events.AggregateTypeFile, current.FileId, &fileEventData{
+			UserId:           current.UserId,
+			UserFileResponse: response,
+		})
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, apperrors.New(apperrors.KindQuotaExceeded, apperrors.CodeQuotaExceeded, "%v", err)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.Conflict(apperrors.CodeFileDeleted, "user file %s was deleted", file.FileId)
+	}
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating user file version")
+	}
+
+	u.uploadStored(ctx, current, upload, response)
+	return response, nil
+}
+
+// pruneVersions deletes the oldest versions of a file beyond the configured maximum
+// once latest is its current version, as part of the transaction tx, releasing their
+// content.
+func (u *userService) pruneVersions(tx *gorm.DB, fileId string, latest int) error {
+	if u.Config.FileMaxVersions <= 0 {
+		return nil
+	}
+
+	expired, err := u.UserFileVersionRepository.WithTx(tx).DeleteOlderThan(fileId, latest-u.Config.FileMaxVersions+1)
+	if err != nil {
+		return err
+	}
+	for _, version := range expired {
+		if err := u.FileStorage.Release(tx, version.Sha256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	webhookmodel "github.com/pedromspeixoto/users-api/internal/data/models/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/pkg/backoff"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"github.com/pedromspeixoto/users-api/internal/pkg/webhooks"
+	"go.uber.org/fx"
+)
+
+func InvokeDispatcher() fx.Option {
+	return fx.Invoke(NewDispatcher)
+}
+
+type dispatcherDeps struct {
+	fx.In
+
+	LifeCycle                     fx.Lifecycle
+	Config                        *config.Config
+	Logger                        *logger.LoggingClient
+	KeyProvider                   encryption.KeyProvider
+	WebhookSubscriptionRepository webhookmodel.WebhookSubscriptionRepository
+	WebhookDeliveryRepository     webhookmodel.WebhookDeliveryRepository
+}
+
+// Dispatcher polls pending webhook deliveries and posts them, signed, to their
+// subscription url. Deliveries are claimed for a lease so replicas don't post the
+// same ones. Failed deliveries are retried with exponential backoff until the
+// maximum number of attempts is reached, after which they are dead-lettered.
+// Subscription urls are supplied by callers, so only public addresses are dialed
+// and redirects are not followed.
+type Dispatcher struct {
+	dispatcherDeps
+	logger.Logger
+
+	id         string
+	httpClient *http.Client
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewDispatcher(deps dispatcherDeps) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return files.CheckAddress(address)
+		},
+	}
+	dispatcher := &Dispatcher{
+		dispatcherDeps: deps,
+		Logger:         deps.Logger.GetLogger(),
+		id:             uuid.GenerateUUID(),
+		httpClient: &http.Client{
+			Timeout: deps.Config.WebhookTimeout,
+			Transport: &http.Transport{
+				// never go through a proxy, it would dial on our behalf without checks
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 10 * time.Second,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       90 * time.Second,
+			},
+			// a redirect would reach an url that was never checked, it fails the attempt
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	deps.LifeCycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			dispatcher.Info("starting webhook dispatcher")
+			go dispatcher.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			dispatcher.Info("stopping webhook dispatcher")
+			close(dispatcher.stop)
+			select {
+			case <-dispatcher.done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return dispatcher
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.Config.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.dispatchDue()
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue() {
+	deliveries, err := d.WebhookDeliveryRepository.Claim(d.id, d.Config.WebhookClaimLease, d.Config.WebhookBatchSize)
+	if err != nil {
+		d.Errorf("failed to claim due webhook deliveries: %v", err)
+		return
+	}
+
+	for i := range deliveries {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+		// another dispatcher may have claimed the delivery once its lease expired
+		if !time.Now().Before(deliveries[i].NextAttemptAt) {
+			return
+		}
+		d.dispatch(&deliveries[i])
+	}
+}
+
+func (d *Dispatcher) dispatch(delivery *webhookmodel.WebhookDelivery) {
+	subscription, err := d.WebhookSubscriptionRepository.GetByUUID(delivery.SubscriptionId)
+	switch {
+	case err != nil:
+		d.fail(delivery, fmt.Errorf("subscription no longer exists"), true)
+	case !subscription.Active:
+		d.fail(delivery, fmt.Errorf("subscription is not active"), true)
+	default:
+		statusCode, err := d.post(subscription, delivery)
+		delivery.ResponseStatus = statusCode
+		if err != nil {
+			d.fail(delivery, err, delivery.Attempts >= d.Config.WebhookMaxAttempts)
+			break
+		}
+
+		now := time.Now()
+		delivery.Status = webhookmodel.DeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+	}
+
+	if err = d.WebhookDeliveryRepository.Record(delivery); err != nil {
+		// the delivery will be attempted again once its lease expired
+		d.Errorf("failed to record webhook delivery %s: %v", delivery.DeliveryId, err)
+	}
+}
+
+func (d *Dispatcher) fail(delivery *webhookmodel.WebhookDelivery, cause error, dead bool) {
+	message := cause.Error()
+	delivery.LastError = &message
+
+	if dead {
+		d.Warningf("webhook delivery %s is dead after %d attempts: %v", delivery.DeliveryId, delivery.Attempts, cause)
+		delivery.Status = webhookmodel.DeliveryStatusDead
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(backoff.Exponential(delivery.Attempts, 10*time.Second, d.Config.WebhookMaxBackoff))
+}
+
+func (d *Dispatcher) post(subscription *webhookmodel.WebhookSubscription, delivery *webhookmodel.WebhookDelivery) (*int, error) {
+	body := []byte(delivery.Payload)
+
+	secret, err := openSecret(d.KeyProvider, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt webhook secret: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "users-api-webhooks/1.0")
+	req.Header.Set(webhooks.DeliveryHeader, delivery.DeliveryId)
+	req.Header.Set(webhooks.EventHeader, delivery.EventType)
+	req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(secret, time.Now(), body))
+
+	response, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	statusCode := response.StatusCode
+	if statusCode < 200 || statusCode >= 300 {
+		return &statusCode, fmt.Errorf("unexpected webhook response status %d", statusCode)
+	}
+	return &statusCode, nil
+}
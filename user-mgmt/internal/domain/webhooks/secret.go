@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	webhookmodel "github.com/pedromspeixoto/users-api/internal/data/models/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+)
+
+// rotationBatchSize is the number of subscriptions re-encrypted per query by a key
+// rotation.
+const rotationBatchSize = 100
+
+// sealSecret stores secret in subscription, encrypted with a new data key wrapped by
+// the active master key.
+func sealSecret(provider encryption.KeyProvider, subscription *webhookmodel.WebhookSubscription, secret string) error {
+	envelope, err := encryption.Seal(provider, []byte(secret))
+	if err != nil {
+		return err
+	}
+	subscription.Secret = envelope.Ciphertext
+	subscription.SecretKeyId = &envelope.KeyId
+	subscription.SecretWrappedKey = envelope.WrappedKey
+	return nil
+}
+
+// openSecret returns the secret of subscription, decrypting it unless it was stored
+// before encryption was introduced.
+func openSecret(provider encryption.KeyProvider, subscription *webhookmodel.WebhookSubscription) (string, error) {
+	if subscription.SecretKeyId == nil {
+		return string(subscription.Secret), nil
+	}
+	secret, err := encryption.Open(provider, &encryption.Envelope{
+		KeyId:      *subscription.SecretKeyId,
+		WrappedKey: subscription.SecretWrappedKey,
+		Ciphertext: subscription.Secret,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (w *webhookService) RotateSecrets(ctx context.Context) (int, error) {
+	keyId := w.KeyProvider.ActiveKeyId()
+
+	rotated := 0
+	var afterId uint
+	for {
+		subscriptions, err := w.WebhookSubscriptionRepository.ListSecretsNotWrappedBy(keyId, afterId, rotationBatchSize)
+		if err != nil {
+			return rotated, err
+		}
+		if len(subscriptions) == 0 {
+			return rotated, nil
+		}
+
+		for i := range subscriptions {
+			if err = ctx.Err(); err != nil {
+				return rotated, err
+			}
+			subscription := &subscriptions[i]
+			afterId = subscription.ID
+
+			secret, err := openSecret(w.KeyProvider, subscription)
+			if err != nil {
+				return rotated, fmt.Errorf("could not decrypt the secret of webhook %s: %v", subscription.SubscriptionId, err)
+			}
+			if err = sealSecret(w.KeyProvider, subscription, secret); err != nil {
+				return rotated, err
+			}
+			if err = w.WebhookSubscriptionRepository.UpdateSecret(subscription); err != nil {
+				return rotated, err
+			}
+			rotated++
+		}
+	}
+}
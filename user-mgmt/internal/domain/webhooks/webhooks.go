@@ -0,0 +1,243 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/data/models/outbox"
+	webhookmodel "github.com/pedromspeixoto/users-api/internal/data/models/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/events"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	webhooksdto "github.com/pedromspeixoto/users-api/internal/dto/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/pkg/encryption"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// WebhookService provides methods pertaining to managing webhook subscriptions
+// and their deliveries.
+type WebhookService interface {
+	// CreateWebhook creates a new webhook subscription
+	CreateWebhook(ctx context.Context, request *webhooksdto.WebhookRequest) (*webhooksdto.WebhookResponse, error)
+	// ListWebhooks retrieves all webhook subscriptions with pagination.
+	ListWebhooks(ctx context.Context, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+	// GetWebhook retrieves a webhook subscription by uuid
+	GetWebhook(ctx context.Context, uuid string) (*webhooksdto.WebhookResponse, error)
+	// UpdateWebhook updates the url, event types or state of a webhook subscription
+	UpdateWebhook(ctx context.Context, uuid string, request *webhooksdto.WebhookUpdateRequest) (*webhooksdto.WebhookResponse, error)
+	// DeleteWebhook soft deletes a webhook subscription by uuid
+	DeleteWebhook(ctx context.Context, uuid string) error
+
+	// ListDeliveries retrieves the delivery history of a webhook subscription with pagination.
+	ListDeliveries(ctx context.Context, uuid string, status string, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+	// ListDeadLetters retrieves the deliveries that exhausted their attempts with pagination.
+	ListDeadLetters(ctx context.Context, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error)
+	// RetryDelivery schedules a dead delivery for a new round of attempts
+	RetryDelivery(ctx context.Context, uuid string, deliveryId string) (*webhooksdto.WebhookDeliveryResponse, error)
+
+	// RotateSecrets encrypts the secret of every subscription with a data key wrapped
+	// by the active master key, returning how many were encrypted again.
+	RotateSecrets(ctx context.Context) (int, error)
+
+	// EnqueueDeliveries creates a delivery of the event for every interested subscription
+	// as part of the transaction tx.
+	EnqueueDeliveries(tx *gorm.DB, event *outbox.OutboxEvent) error
+}
+
+type WebhookServiceDeps struct {
+	fx.In
+
+	Logger                        *logger.LoggingClient
+	KeyProvider                   encryption.KeyProvider
+	WebhookSubscriptionRepository webhookmodel.WebhookSubscriptionRepository
+	WebhookDeliveryRepository     webhookmodel.WebhookDeliveryRepository
+}
+
+type webhookService struct {
+	WebhookServiceDeps
+	logger.Logger
+}
+
+func NewWebhookService(deps WebhookServiceDeps) WebhookService {
+	return &webhookService{
+		WebhookServiceDeps: deps,
+		Logger:             deps.Logger.GetLogger(),
+	}
+}
+
+func (w *webhookService) CreateWebhook(ctx context.Context, request *webhooksdto.WebhookRequest) (*webhooksdto.WebhookResponse, error) {
+	secret := request.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, apperrors.Internal(err, "unexpected error generating webhook secret")
+		}
+		secret = generated
+	}
+
+	model := webhooksdto.ModelFromWebhookRequest(request)
+	if err := sealSecret(w.KeyProvider, model, secret); err != nil {
+		return nil, apperrors.Internal(err, "unexpected error encrypting webhook secret")
+	}
+	err := w.WebhookSubscriptionRepository.Create(model)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error creating new webhook")
+	}
+
+	// the secret is only disclosed once, on creation
+	response := webhooksdto.NewWebhookResponse(model)
+	response.Secret = secret
+
+	return response, nil
+}
+
+func (w *webhookService) ListWebhooks(ctx context.Context, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	subscriptions, pageEnv, err := w.WebhookSubscriptionRepository.List(paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching webhooks")
+	}
+
+	pageEnv.Data = webhooksdto.NewWebhookListResponse(subscriptions)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func (w *webhookService) GetWebhook(ctx context.Context, uuid string) (*webhooksdto.WebhookResponse, error) {
+	subscription, err := w.WebhookSubscriptionRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeWebhookNotFound, "webhook %s not found", uuid)
+	}
+
+	return webhooksdto.NewWebhookResponse(subscription), nil
+}
+
+func (w *webhookService) UpdateWebhook(ctx context.Context, uuid string, request *webhooksdto.WebhookUpdateRequest) (*webhooksdto.WebhookResponse, error) {
+	subscription, err := w.WebhookSubscriptionRepository.GetByUUID(uuid)
+	if err != nil {
+		return nil, apperrors.Lookup(err, apperrors.CodeWebhookNotFound, "webhook %s not found", uuid)
+	}
+
+	if request.Url != nil {
+		subscription.Url = *request.Url
+	}
+	if request.EventTypes != nil {
+		subscription.EventTypes = strings.Join(request.EventTypes, ",")
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+
+	err = w.WebhookSubscriptionRepository.Update(subscription)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error updating webhook")
+	}
+
+	return webhooksdto.NewWebhookResponse(subscription), nil
+}
+
+func (w *webhookService) DeleteWebhook(ctx context.Context, uuid string) error {
+	subscription, err := w.WebhookSubscriptionRepository.GetByUUID(uuid)
+	if err != nil {
+		return apperrors.Lookup(err, apperrors.CodeWebhookNotFound, "webhook %s not found", uuid)
+	}
+
+	err = w.WebhookSubscriptionRepository.SoftDelete(subscription)
+	if err != nil {
+		return apperrors.Internal(err, "unexpected error deleting webhook")
+	}
+
+	return nil
+}
+
+func (w *webhookService) ListDeliveries(ctx context.Context, uuid string, status string, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	// check if webhook exists
+	_, err := w.GetWebhook(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, pageEnv, err := w.WebhookDeliveryRepository.List(uuid, status, paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching webhook deliveries")
+	}
+
+	pageEnv.Data = webhooksdto.NewWebhookDeliveryListResponse(deliveries)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func (w *webhookService) ListDeadLetters(ctx context.Context, paginationRequest *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	deliveries, pageEnv, err := w.WebhookDeliveryRepository.List("", webhookmodel.DeliveryStatusDead, paginationRequest.Limit, paginationRequest.Page)
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error fetching dead webhook deliveries")
+	}
+
+	pageEnv.Data = webhooksdto.NewWebhookDeliveryListResponse(deliveries)
+	return dto.NewPaginationResponse(pageEnv), nil
+}
+
+func (w *webhookService) RetryDelivery(ctx context.Context, uuid string, deliveryId string) (*webhooksdto.WebhookDeliveryResponse, error) {
+	delivery, err := w.WebhookDeliveryRepository.GetByUUID(deliveryId)
+	if err != nil || delivery.SubscriptionId != uuid {
+		return nil, apperrors.NotFound(apperrors.CodeDeliveryNotFound, "webhook delivery %s not found", deliveryId)
+	}
+	if delivery.Status != webhookmodel.DeliveryStatusDead {
+		return nil, apperrors.Conflict(apperrors.CodeDeliveryNotRetryable, "only dead deliveries can be retried, delivery is %s", delivery.Status)
+	}
+
+	// a concurrent retry may have won
+	err = w.WebhookDeliveryRepository.Retry(delivery)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.Conflict(apperrors.CodeDeliveryNotRetryable, "only dead deliveries can be retried, delivery was retried already")
+	}
+	if err != nil {
+		return nil, apperrors.Internal(err, "unexpected error retrying webhook delivery")
+	}
+
+	return webhooksdto.NewWebhookDeliveryResponse(delivery), nil
+}
+
+func (w *webhookService) EnqueueDeliveries(tx *gorm.DB, event *outbox.OutboxEvent) error {
+	subscriptions, err := w.WebhookSubscriptionRepository.WithTx(tx).ListActiveByEventType(event.EventType)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(events.Envelope(event))
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		err = w.WebhookDeliveryRepository.WithTx(tx).Create(&webhookmodel.WebhookDelivery{
+			DeliveryId:     uuid.GenerateUUID(),
+			SubscriptionId: subscription.SubscriptionId,
+			EventId:        event.EventId,
+			EventType:      event.EventType,
+			Payload:        string(payload),
+			Status:         webhookmodel.DeliveryStatusPending,
+			NextAttemptAt:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
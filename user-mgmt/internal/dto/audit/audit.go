@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	auditmodel "github.com/pedromspeixoto/users-api/internal/data/models/audit"
+)
+
+// request
+type AuditEventFilterRequest struct {
+	Actor      string     `json:"actor,omitempty"`
+	Action     string     `json:"action,omitempty"`
+	TargetType string     `json:"target_type,omitempty"`
+	TargetId   string     `json:"target_id,omitempty"`
+	RequestId  string     `json:"request_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+}
+
+func ModelFromAuditEventFilterRequest(f *AuditEventFilterRequest) *auditmodel.AuditEventFilter {
+	model := &auditmodel.AuditEventFilter{
+		Actor:      f.Actor,
+		Action:     f.Action,
+		TargetType: f.TargetType,
+		TargetId:   f.TargetId,
+		RequestId:  f.RequestId,
+		From:       f.From,
+		To:         f.To,
+	}
+	return model
+}
+
+// response
+type AuditEventResponse struct {
+	EventId    string          `json:"event_id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetId   string          `json:"target_id"`
+	RequestId  string          `json:"request_id,omitempty"`
+	ClientIp   string          `json:"client_ip,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func NewAuditEventResponse(event *auditmodel.AuditEvent) *AuditEventResponse {
+	resp := &AuditEventResponse{
+		EventId:    event.EventId,
+		Actor:      event.Actor,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetId:   event.TargetId,
+		RequestId:  event.RequestId,
+		ClientIp:   event.ClientIp,
+		CreatedAt:  event.CreatedAt,
+	}
+	if event.BeforeSnapshot != nil {
+		resp.Before = json.RawMessage(*event.BeforeSnapshot)
+	}
+	if event.AfterSnapshot != nil {
+		resp.After = json.RawMessage(*event.AfterSnapshot)
+	}
+	return resp
+}
+
+type AuditEventListResponse struct {
+	AuditEvents []AuditEventResponse `json:"audit_events,omitempty"`
+}
+
+func NewAuditEventListResponse(models []auditmodel.AuditEvent) *AuditEventListResponse {
+	var events []AuditEventResponse
+	for i := range models {
+		events = append(events, *NewAuditEventResponse(&models[i]))
+	}
+	return &AuditEventListResponse{AuditEvents: events}
+}
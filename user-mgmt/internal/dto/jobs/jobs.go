@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	jobmodel "github.com/pedromspeixoto/users-api/internal/data/models/jobs"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+)
+
+func ModelFromFileIngestRequest(userId string, request *usersdto.UserFileRequest, actor string, requestId string) (*jobmodel.Job, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(payload)
+
+	model := &jobmodel.Job{
+		JobId:   uuid.GenerateUUID(),
+		Type:    jobmodel.TypeFileIngest,
+		UserId:  userId,
+		Payload: &encoded,
+		Actor:   actor,
+		Status:  jobmodel.StatusQueued,
+	}
+	if request.Url != "" {
+		model.SourceUrl = &request.Url
+	}
+	if requestId != "" {
+		model.RequestId = &requestId
+	}
+	return model, nil
+}
+
+// FileIngestRequestFromModel returns the request a file ingest job was queued with.
+func FileIngestRequestFromModel(job *jobmodel.Job) (*usersdto.UserFileRequest, error) {
+	request := &usersdto.UserFileRequest{}
+	// jobs queued before payloads were stored only carry their url
+	if job.Payload == nil {
+		if job.SourceUrl != nil {
+			request.Url = *job.SourceUrl
+		}
+		return request, nil
+	}
+	if err := json.Unmarshal([]byte(*job.Payload), request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// response
+type JobResponse struct {
+	JobId           string     `json:"job_id"`
+	Type            string     `json:"type"`
+	UserId          string     `json:"user_id"`
+	SourceUrl       *string    `json:"source_url,omitempty"`
+	Status          string     `json:"status"`
+	Progress        int        `json:"progress"`
+	Attempts        int        `json:"attempts"`
+	FileId          *string    `json:"file_id,omitempty"`
+	Error           *string    `json:"error,omitempty"`
+	ErrorCode       *int       `json:"error_code,omitempty"`
+	Code            *string    `json:"code,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func NewJobResponse(job *jobmodel.Job) *JobResponse {
+	resp := &JobResponse{
+		JobId:           job.JobId,
+		Type:            job.Type,
+		UserId:          job.UserId,
+		SourceUrl:       job.SourceUrl,
+		Status:          job.Status,
+		Progress:        job.Progress,
+		Attempts:        job.Attempts,
+		FileId:          job.ResultId,
+		Error:           job.Error,
+		ErrorCode:       job.ErrorCode,
+		Code:            job.Code,
+		CancelRequested: job.CancelRequested,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+		CreatedAt:       job.CreatedAt,
+	}
+	return resp
+}
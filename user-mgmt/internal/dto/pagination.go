@@ -1,9 +1,18 @@
 package dto
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pedromspeixoto/users-api/internal/data"
 )
 
+const (
+	DefaultLimit int    = 10
+	DefaultPage  int    = 1
+	DefaultSort  string = "created_at asc"
+)
+
 type PaginationRequest struct {
 	Limit  int               `json:"limit,omitempty"`
 	Page   int               `json:"page,omitempty"`
@@ -23,6 +32,21 @@ func NewPaginationRequest(limit, page int, sort string, filter, search map[strin
 	return res, nil
 }
 
+// ParseSort converts a sort like field.asc or field.desc to an order clause.
+func ParseSort(sort string) (string, error) {
+	splits := strings.Split(sort, ".")
+	if len(splits) != 2 {
+		return "", fmt.Errorf("malformed sort query, should be field.orderdirection")
+	}
+
+	field, order := splits[0], splits[1]
+	if order != "desc" && order != "asc" {
+		return "", fmt.Errorf("malformed order in sort query, should be asc or desc")
+	}
+
+	return fmt.Sprintf("%s %s", field, strings.ToUpper(order)), nil
+}
+
 func ModelFromPaginationRequest(p *PaginationRequest) *data.Pagination {
 	model := &data.Pagination{
 		Limit:  p.Limit,
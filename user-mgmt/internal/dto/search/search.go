@@ -0,0 +1,35 @@
+package search
+
+import (
+	"time"
+
+	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
+)
+
+// response
+type FileSearchResponse struct {
+	FileId    string    `json:"file_id"`
+	UserId    string    `json:"user_id"`
+	FileType  string    `json:"file_type"`
+	FileSize  int64     `json:"file_size"`
+	Score     float64   `json:"score"`
+	Snippets  []string  `json:"snippets"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewFileSearchResponse(result *usermodel.UserFileSearchResult, snippets []string) *FileSearchResponse {
+	resp := &FileSearchResponse{
+		FileId:    result.FileId,
+		UserId:    result.UserId,
+		FileType:  result.FileType,
+		FileSize:  result.FileSize,
+		Score:     result.Score,
+		Snippets:  snippets,
+		CreatedAt: result.CreatedAt,
+	}
+	return resp
+}
+
+type FileSearchListResponse struct {
+	Files []FileSearchResponse `json:"files,omitempty"`
+}
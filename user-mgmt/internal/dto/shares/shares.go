@@ -0,0 +1,55 @@
+package shares
+
+import (
+	"time"
+
+	sharemodel "github.com/pedromspeixoto/users-api/internal/data/models/shares"
+)
+
+// request
+type ShareRequest struct {
+	// ExpiresIn is the lifetime of the link in seconds, the configured default when omitted.
+	ExpiresIn int64 `json:"expires_in,omitempty" validate:"omitempty,min=1"`
+	// MaxDownloads limits the number of downloads through the link, unlimited when omitted.
+	MaxDownloads *int `json:"max_downloads,omitempty" validate:"omitempty,min=1"`
+}
+
+// response
+type ShareResponse struct {
+	ShareId string `json:"share_id"`
+	FileId  string `json:"file_id"`
+	// Url is the signed link to the file, only returned when the share is created.
+	Url           string     `json:"url,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	MaxDownloads  *int       `json:"max_downloads,omitempty"`
+	DownloadCount int        `json:"download_count"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func NewShareResponse(share *sharemodel.FileShare) *ShareResponse {
+	resp := &ShareResponse{
+		ShareId:       share.ShareId,
+		FileId:        share.FileId,
+		ExpiresAt:     share.ExpiresAt,
+		MaxDownloads:  share.MaxDownloads,
+		DownloadCount: share.DownloadCount,
+		RevokedAt:     share.RevokedAt,
+		CreatedBy:     share.CreatedBy,
+		CreatedAt:     share.CreatedAt,
+	}
+	return resp
+}
+
+type ShareListResponse struct {
+	Shares []ShareResponse `json:"shares,omitempty"`
+}
+
+func NewShareListResponse(models []sharemodel.FileShare) *ShareListResponse {
+	var shares []ShareResponse
+	for i := range models {
+		shares = append(shares, *NewShareResponse(&models[i]))
+	}
+	return &ShareListResponse{Shares: shares}
+}
@@ -1,22 +1,118 @@
 package users
 
 import (
-	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
+	"io"
 	"time"
+
+	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
 )
 
+// request
+type UserFileRequest struct {
+	// Url imports the file from the given url instead of the file serving service.
+	Url string `json:"url,omitempty" validate:"omitempty,url"`
+	// Filename is the original name of the file, the last segment of Url by default.
+	Filename    string   `json:"filename,omitempty" validate:"omitempty,max=255"`
+	Description string   `json:"description,omitempty" validate:"omitempty,max=4096"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,required,max=64"`
+	// ExpiresAt is when the file is deleted, the retention of its type by default.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// UserFileUpdateRequest changes the details of a user file, omitted fields are kept.
+// An empty filename or description removes it, an empty list removes every tag and
+// NoExpiry removes the expiry.
+type UserFileUpdateRequest struct {
+	Filename    *string    `json:"filename,omitempty" validate:"omitempty,max=255"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=4096"`
+	Tags        *[]string  `json:"tags,omitempty" validate:"omitempty,max=20,dive,required,max=64"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" validate:"excluded_with=NoExpiry"`
+	NoExpiry    bool       `json:"no_expiry,omitempty"`
+}
+
+type UserFileFilterRequest struct {
+	FileType  string   `json:"file_type,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	MinPages  *int     `json:"min_pages,omitempty"`
+	MaxPages  *int     `json:"max_pages,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Author    string   `json:"author,omitempty"`
+	Encrypted *bool    `json:"encrypted,omitempty"`
+}
+
+func ModelFromUserFileFilterRequest(f *UserFileFilterRequest) *usermodel.UserFileFilter {
+	if f == nil {
+		return nil
+	}
+	model := &usermodel.UserFileFilter{
+		FileType:  f.FileType,
+		Tags:      f.Tags,
+		MinPages:  f.MinPages,
+		MaxPages:  f.MaxPages,
+		Title:     f.Title,
+		Author:    f.Author,
+		Encrypted: f.Encrypted,
+	}
+	return model
+}
+
 // response
 type UserFileResponse struct {
-	FileId    string    `json:"file_id"`
-	FileType  string    `json:"file_type"`
-	CreatedAt time.Time `json:"created_at"`
+	FileId      string   `json:"file_id"`
+	Filename    *string  `json:"filename,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags"`
+	FileType    string   `json:"file_type"`
+	FileSize    int64    `json:"file_size"`
+	Sha256      string   `json:"sha256"`
+	Version     int      `json:"version"`
+	Status      string   `json:"status"`
+	// QuarantineReason names the malware detected in a quarantined file.
+	QuarantineReason *string                   `json:"quarantine_reason,omitempty"`
+	Metadata         *UserFileMetadataResponse `json:"metadata,omitempty"`
+	ExpiresAt        *time.Time                `json:"expires_at,omitempty"`
+	CreatedAt        time.Time                 `json:"created_at"`
+}
+
+type UserFileMetadataResponse struct {
+	PageCount  int        `json:"page_count"`
+	Title      string     `json:"title,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	Encrypted  bool       `json:"encrypted"`
+	PdfVersion string     `json:"pdf_version,omitempty"`
+}
+
+func NewUserFileMetadataResponse(metadata *usermodel.UserFileMetadata) *UserFileMetadataResponse {
+	if metadata == nil {
+		return nil
+	}
+	resp := &UserFileMetadataResponse{
+		PageCount:  metadata.PageCount,
+		Title:      metadata.Title,
+		Author:     metadata.Author,
+		CreatedAt:  metadata.PdfCreatedAt,
+		Encrypted:  metadata.Encrypted,
+		PdfVersion: metadata.PdfVersion,
+	}
+	return resp
 }
 
 func NewUserFileResponse(userFile *usermodel.UserFile) *UserFileResponse {
 	resp := &UserFileResponse{
-		FileId:    userFile.FileId,
-		FileType:  userFile.FileType,
-		CreatedAt: userFile.CreatedAt,
+		FileId:           userFile.FileId,
+		Filename:         userFile.Filename,
+		Description:      userFile.Description,
+		Tags:             userFile.TagNames(),
+		FileType:         userFile.FileType,
+		FileSize:         userFile.FileSize,
+		Sha256:           userFile.Sha256,
+		Version:          userFile.Version,
+		Status:           userFile.Status,
+		QuarantineReason: userFile.QuarantineReason,
+		Metadata:         NewUserFileMetadataResponse(userFile.Metadata),
+		ExpiresAt:        userFile.ExpiresAt,
+		CreatedAt:        userFile.CreatedAt,
 	}
 	return resp
 }
@@ -27,12 +123,83 @@ type UserFileListResponse struct {
 
 func NewUserFileListResponse(models []usermodel.UserFile) *UserFileListResponse {
 	var userFiles []UserFileResponse
+	for i := range models {
+		userFiles = append(userFiles, *NewUserFileResponse(&models[i]))
+	}
+	return &UserFileListResponse{UserFiles: userFiles}
+}
+
+type UserFileVersionResponse struct {
+	FileId   string `json:"file_id"`
+	Version  int    `json:"version"`
+	Current  bool   `json:"current"`
+	FileType string `json:"file_type"`
+	FileSize int64  `json:"file_size"`
+	Sha256   string `json:"sha256"`
+	Status   string `json:"status"`
+	// QuarantineReason names the malware detected in a quarantined version.
+	QuarantineReason *string   `json:"quarantine_reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type UserFileVersionListResponse struct {
+	Versions []UserFileVersionResponse `json:"versions,omitempty"`
+}
+
+func NewUserFileVersionListResponse(models []usermodel.UserFileVersion, currentVersion int) *UserFileVersionListResponse {
+	var versions []UserFileVersionResponse
 	for _, m := range models {
-		userFiles = append(userFiles, UserFileResponse{
-			FileId:    m.FileId,
-			FileType:  m.FileType,
-			CreatedAt: m.CreatedAt,
+		versions = append(versions, UserFileVersionResponse{
+			FileId:           m.FileId,
+			Version:          m.Version,
+			Current:          m.Version == currentVersion,
+			FileType:         m.FileType,
+			FileSize:         m.FileSize,
+			Sha256:           m.Sha256,
+			Status:           m.Status,
+			QuarantineReason: m.QuarantineReason,
+			CreatedAt:        m.CreatedAt,
 		})
 	}
-	return &UserFileListResponse{UserFiles: userFiles}
+	return &UserFileVersionListResponse{Versions: versions}
+}
+
+// UserFileDownload is the content of a user file with what is needed to serve it.
+type UserFileDownload struct {
+	Content  []byte
+	FileType string
+	Filename string
+	Sha256   string
+}
+
+// UserUsageResponse reports the storage used by a user against its quotas, a zero
+// limit means unlimited.
+type UserUsageResponse struct {
+	UserId      string `json:"user_id"`
+	FileCount   int64  `json:"file_count"`
+	MaxFiles    int64  `json:"max_files"`
+	UsedBytes   int64  `json:"used_bytes"`
+	MaxBytes    int64  `json:"max_bytes"`
+	MaxFileSize int64  `json:"max_file_size"`
+}
+
+// UserFileArchive is a ZIP archive of user files, streamed to w by Write.
+type UserFileArchive struct {
+	Filename string
+	Write    func(w io.Writer) error
+}
+
+// UserFileArchiveManifest describes the files of an archive, it is stored in the
+// archive as manifest.json.
+type UserFileArchiveManifest struct {
+	UserId    string                 `json:"user_id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Files     []UserFileArchiveEntry `json:"files"`
+}
+
+type UserFileArchiveEntry struct {
+	// Path is the location of the file in the archive, quarantined files are listed
+	// without their content.
+	Path string `json:"path,omitempty"`
+	*UserFileResponse
 }
@@ -1,6 +1,8 @@
 package users
 
 import (
+	"time"
+
 	usermodel "github.com/pedromspeixoto/users-api/internal/data/models/users"
 	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
 )
@@ -18,16 +20,24 @@ func ModelFromUserRequest(post *UserRequest) *usermodel.User {
 	return model
 }
 
+type VerifyUserRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 // response
 type UserResponse struct {
-	UserId string `json:"user_id"`
-	Email  string `json:"email"`
+	UserId          string     `json:"user_id"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
 func NewUserResponse(user *usermodel.User) *UserResponse {
 	resp := &UserResponse{
-		UserId: user.UserId,
-		Email:  user.Email,
+		UserId:          user.UserId,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+		EmailVerifiedAt: user.EmailVerifiedAt,
 	}
 	return resp
 }
@@ -40,8 +50,10 @@ func NewUserListResponse(models []usermodel.User) *UserListResponse {
 	var users []UserResponse
 	for _, m := range models {
 		users = append(users, UserResponse{
-			UserId: m.UserId,
-			Email:  m.Email,
+			UserId:          m.UserId,
+			Email:           m.Email,
+			EmailVerified:   m.EmailVerifiedAt != nil,
+			EmailVerifiedAt: m.EmailVerifiedAt,
 		})
 	}
 	return &UserListResponse{Users: users}
@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"strings"
+	"time"
+
+	webhookmodel "github.com/pedromspeixoto/users-api/internal/data/models/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+)
+
+// request
+type WebhookRequest struct {
+	Url        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types,omitempty" validate:"dive,oneof=user.created user.deleted file.created file.deleted file.updated"`
+	Secret     string   `json:"secret,omitempty" validate:"omitempty,min=16"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// ModelFromWebhookRequest creates a subscription from request, without its secret
+// which is stored encrypted.
+func ModelFromWebhookRequest(request *WebhookRequest) *webhookmodel.WebhookSubscription {
+	model := &webhookmodel.WebhookSubscription{
+		SubscriptionId: uuid.GenerateUUID(),
+		Url:            request.Url,
+		EventTypes:     strings.Join(request.EventTypes, ","),
+		Active:         request.Active == nil || *request.Active,
+	}
+	return model
+}
+
+type WebhookUpdateRequest struct {
+	Url        *string  `json:"url,omitempty" validate:"omitempty,url"`
+	EventTypes []string `json:"event_types,omitempty" validate:"dive,oneof=user.created user.deleted file.created file.deleted file.updated"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// response
+type WebhookResponse struct {
+	WebhookId  string    `json:"webhook_id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewWebhookResponse(subscription *webhookmodel.WebhookSubscription) *WebhookResponse {
+	resp := &WebhookResponse{
+		WebhookId:  subscription.SubscriptionId,
+		Url:        subscription.Url,
+		EventTypes: SplitEventTypes(subscription.EventTypes),
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+	}
+	return resp
+}
+
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks,omitempty"`
+}
+
+func NewWebhookListResponse(models []webhookmodel.WebhookSubscription) *WebhookListResponse {
+	var webhooks []WebhookResponse
+	for i := range models {
+		webhooks = append(webhooks, *NewWebhookResponse(&models[i]))
+	}
+	return &WebhookListResponse{Webhooks: webhooks}
+}
+
+type WebhookDeliveryResponse struct {
+	DeliveryId     string     `json:"delivery_id"`
+	WebhookId      string     `json:"webhook_id"`
+	EventId        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func NewWebhookDeliveryResponse(delivery *webhookmodel.WebhookDelivery) *WebhookDeliveryResponse {
+	resp := &WebhookDeliveryResponse{
+		DeliveryId:     delivery.DeliveryId,
+		WebhookId:      delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == webhookmodel.DeliveryStatusPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return resp
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries,omitempty"`
+}
+
+func NewWebhookDeliveryListResponse(models []webhookmodel.WebhookDelivery) *WebhookDeliveryListResponse {
+	var deliveries []WebhookDeliveryResponse
+	for i := range models {
+		deliveries = append(deliveries, *NewWebhookDeliveryResponse(&models[i]))
+	}
+	return &WebhookDeliveryListResponse{Deliveries: deliveries}
+}
+
+// SplitEventTypes splits a comma separated list of event types.
+func SplitEventTypes(eventTypes string) []string {
+	if eventTypes == "" {
+		return []string{}
+	}
+	return strings.Split(eventTypes, ",")
+}
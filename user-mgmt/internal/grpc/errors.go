@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail of errors, whose reason is the
+// stable error code.
+const ErrorDomain = "users-api"
+
+// kindCodes maps the kinds of domain errors to status codes.
+var kindCodes = map[apperrors.Kind]codes.Code{
+	apperrors.KindInternal:             codes.Internal,
+	apperrors.KindInvalid:              codes.InvalidArgument,
+	apperrors.KindUnauthenticated:      codes.Unauthenticated,
+	apperrors.KindForbidden:            codes.PermissionDenied,
+	apperrors.KindNotFound:             codes.NotFound,
+	apperrors.KindConflict:             codes.AlreadyExists,
+	apperrors.KindGone:                 codes.NotFound,
+	apperrors.KindUnprocessable:        codes.FailedPrecondition,
+	apperrors.KindTooLarge:             codes.OutOfRange,
+	apperrors.KindUnsupportedMediaType: codes.InvalidArgument,
+	apperrors.KindQuotaExceeded:        codes.ResourceExhausted,
+	apperrors.KindUnavailable:          codes.Unavailable,
+	apperrors.KindUpstream:             codes.Unavailable,
+	apperrors.KindUpstreamTimeout:      codes.DeadlineExceeded,
+}
+
+// toStatus converts err to a status. Domain errors carry their code in an ErrorInfo
+// detail, and validation errors the invalid fields in a BadRequest detail. Statuses
+// are kept as they are.
+func toStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	domainErr := apperrors.As(err)
+	code, ok := kindCodes[domainErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, domainErr.Message)
+	errorInfo := &errdetails.ErrorInfo{
+		Reason: domainErr.Code,
+		Domain: ErrorDomain,
+	}
+	withDetails, err := st.WithDetails(errorInfo)
+	if len(domainErr.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range domainErr.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		withDetails, err = st.WithDetails(errorInfo, badRequest)
+	}
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
@@ -0,0 +1,144 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIdMetadata is the metadata key of the request id, it is generated when the
+// client does not send one and is returned in the response header.
+const RequestIdMetadata = "x-request-id"
+
+// actorMetadata is the metadata key identifying who performed the call, the gRPC
+// counterpart of the actor header.
+var actorMetadata = strings.ToLower(audit.ActorHeader)
+
+// metadataUnary stores the actor, request id and client ip of each call in its
+// context so that the domain can record audit events.
+func metadataUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withAuditMetadata(ctx), req)
+}
+
+func metadataStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withAuditMetadata(ss.Context())})
+}
+
+func withAuditMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestId := firstValue(md, RequestIdMetadata)
+	if requestId == "" {
+		requestId = uuid.GenerateUUID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIdMetadata, requestId))
+
+	var clientIp string
+	if p, ok := peer.FromContext(ctx); ok {
+		clientIp = p.Addr.String()
+		if host, _, err := net.SplitHostPort(clientIp); err == nil {
+			clientIp = host
+		}
+	}
+
+	return audit.WithMetadata(ctx, audit.Metadata{
+		Actor:     firstValue(md, actorMetadata),
+		RequestId: requestId,
+		ClientIp:  clientIp,
+	})
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// observeUnary logs each call with its outcome and converts the errors of the domain
+// to statuses. Like on the HTTP server, the causes of internal errors are logged but
+// not sent to clients.
+func observeUnary(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		t1 := time.Now()
+		resp, err := handler(ctx, req)
+		err = logCall(log, ctx, info.FullMethod, t1, err)
+		return resp, err
+	}
+}
+
+func observeStream(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t1 := time.Now()
+		err := handler(srv, ss)
+		return logCall(log, ss.Context(), info.FullMethod, t1, err)
+	}
+}
+
+func logCall(log logger.Logger, ctx context.Context, method string, t1 time.Time, err error) error {
+	var st *status.Status
+	if err != nil {
+		st = toStatus(err)
+	}
+
+	fields := []zap.Field{
+		zap.String("proto", "grpc"),
+		zap.String("method", method),
+		zap.Duration("lat", time.Since(t1)),
+		zap.String("code", st.Code().String()),
+		zap.String("reqId", audit.MetadataFromContext(ctx).RequestId),
+	}
+	if err != nil {
+		fields = append(fields, zap.String("error", err.Error()))
+	}
+	log.ZapInfo("Served", fields...)
+
+	return st.Err()
+}
+
+// recoverUnary turns panics into internal errors, so that one call cannot take
+// down the server.
+func recoverUnary(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Errorf("panic serving %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "unexpected error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoverStream(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Errorf("panic serving %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "unexpected error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// contextStream is a server stream with the context of the call replaced.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
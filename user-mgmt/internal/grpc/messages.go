@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"time"
+
+	usersv1 "github.com/pedromspeixoto/users-api/api/users/v1"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toUser(user *usersdto.UserResponse) *usersv1.User {
+	return &usersv1.User{
+		UserId:          user.UserId,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerified,
+		EmailVerifiedAt: toTimestamp(user.EmailVerifiedAt),
+	}
+}
+
+func toUserFile(userFile *usersdto.UserFileResponse) *usersv1.UserFile {
+	msg := &usersv1.UserFile{
+		FileId:    userFile.FileId,
+		Tags:      userFile.Tags,
+		FileType:  userFile.FileType,
+		FileSize:  userFile.FileSize,
+		Sha256:    userFile.Sha256,
+		Version:   int32(userFile.Version),
+		Status:    userFile.Status,
+		ExpiresAt: toTimestamp(userFile.ExpiresAt),
+		CreatedAt: timestamppb.New(userFile.CreatedAt),
+	}
+	if userFile.Filename != nil {
+		msg.Filename = *userFile.Filename
+	}
+	if userFile.Description != nil {
+		msg.Description = *userFile.Description
+	}
+	if userFile.QuarantineReason != nil {
+		msg.QuarantineReason = *userFile.QuarantineReason
+	}
+	if metadata := userFile.Metadata; metadata != nil {
+		msg.Metadata = &usersv1.UserFileMetadata{
+			PageCount:  int32(metadata.PageCount),
+			Title:      metadata.Title,
+			Author:     metadata.Author,
+			CreatedAt:  toTimestamp(metadata.CreatedAt),
+			Encrypted:  metadata.Encrypted,
+			PdfVersion: metadata.PdfVersion,
+		}
+	}
+	return msg
+}
+
+func toPageInfo(env *dto.PaginationResponse) *usersv1.PageInfo {
+	return &usersv1.PageInfo{
+		CurrentPage: int32(env.CurrentPage),
+		TotalRows:   env.TotalRows,
+		TotalPages:  int32(env.TotalPages),
+	}
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
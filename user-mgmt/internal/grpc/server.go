@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	validator "github.com/go-playground/validator/v10"
+	usersv1 "github.com/pedromspeixoto/users-api/api/users/v1"
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/domain/health"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func InvokeServer() fx.Option {
+	return fx.Invoke(NewGRPCServer)
+}
+
+type serverDependencies struct {
+	fx.In
+
+	LifeCycle     fx.Lifecycle
+	Config        *config.Config
+	Logger        *logger.LoggingClient
+	Validator     *validator.Validate
+	HealthService health.HealthService
+	UserService   users.UserService
+}
+
+// NewGRPCServer serves the users API over gRPC on its own port, next to the HTTP
+// server, with the reflection and health services.
+func NewGRPCServer(deps serverDependencies) *grpc.Server {
+	log := deps.Logger.GetLogger()
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoverUnary(log), metadataUnary, observeUnary(log)),
+		grpc.ChainStreamInterceptor(recoverStream(log), metadataStream, observeStream(log)),
+	)
+	usersv1.RegisterUserServiceServer(server, newUserServer(deps))
+	reflection.Register(server)
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	stop := make(chan struct{})
+	deps.LifeCycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			listener, err := net.Listen("tcp", fmt.Sprintf(":%s", deps.Config.GrpcPort))
+			if err != nil {
+				return fmt.Errorf("could not listen on gRPC port %s: %v", deps.Config.GrpcPort, err)
+			}
+
+			log.Info("starting gRPC server")
+			go server.Serve(listener)
+			go watchHealth(deps, healthServer, stop)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info("stopping gRPC server")
+			close(stop)
+			healthServer.Shutdown()
+
+			// in-flight calls finish unless the stop deadline comes first
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				server.Stop()
+			}
+			return nil
+		},
+	})
+
+	return server
+}
+
+// watchHealth reports the server as serving while the database answers, until
+// stop is closed.
+func watchHealth(deps serverDependencies, healthServer *grpchealth.Server, stop chan struct{}) {
+	ticker := time.NewTicker(deps.Config.GrpcHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if err := deps.HealthService.GetDbStatus(); err != nil {
+			deps.Logger.GetLogger().Warningf("gRPC server is not serving: %v", err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(usersv1.UserService_ServiceDesc.ServiceName, status)
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
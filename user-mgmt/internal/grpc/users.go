@@ -0,0 +1,323 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	usersv1 "github.com/pedromspeixoto/users-api/api/users/v1"
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// downloadChunkSize is the size of the content sent in each message of a download.
+const downloadChunkSize = 64 * 1024
+
+// userServer implements the gRPC users API with the same UserService as the REST
+// handlers.
+type userServer struct {
+	usersv1.UnimplementedUserServiceServer
+
+	config      *config.Config
+	validator   *validator.Validate
+	userService users.UserService
+}
+
+func newUserServer(deps serverDependencies) *userServer {
+	return &userServer{
+		config:      deps.Config,
+		validator:   deps.Validator,
+		userService: deps.UserService,
+	}
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *usersv1.CreateUserRequest) (*usersv1.User, error) {
+	request := &usersdto.UserRequest{Email: req.GetEmail()}
+	if err := s.validator.Struct(request); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.CreateUser(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.User, error) {
+	user, err := s.userService.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *usersv1.ListUsersRequest) (*usersv1.ListUsersResponse, error) {
+	pageRequest, err := paginationRequest(req.GetPagination())
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := s.userService.ListUsers(ctx, pageRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &usersv1.ListUsersResponse{PageInfo: toPageInfo(env)}
+	if list, ok := env.Data.(*usersdto.UserListResponse); ok {
+		for i := range list.Users {
+			resp.Users = append(resp.Users, toUser(&list.Users[i]))
+		}
+	}
+	return resp, nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *usersv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.userService.DeleteUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *userServer) RestoreUser(ctx context.Context, req *usersv1.RestoreUserRequest) (*usersv1.User, error) {
+	user, err := s.userService.RestoreUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) CreateUserFile(ctx context.Context, req *usersv1.CreateUserFileRequest) (*usersv1.UserFile, error) {
+	request := fileRequest(req.GetDetails())
+	request.Url = req.GetUrl()
+	if err := s.validator.Struct(request); err != nil {
+		return nil, err
+	}
+
+	userFile, err := s.userService.CreateUserFile(ctx, req.GetUserId(), request)
+	if err != nil {
+		return nil, err
+	}
+	return toUserFile(userFile), nil
+}
+
+func (s *userServer) UploadUserFile(stream usersv1.UserService_UploadUserFileServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return apperrors.Invalid(apperrors.CodeInvalidRequest, "the first message of an upload must carry its info")
+	}
+
+	request := fileRequest(info.GetDetails())
+	if err = s.validator.Struct(request); err != nil {
+		return err
+	}
+
+	// the content is limited while it is received, not once it is all in memory
+	var content bytes.Buffer
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if msg.GetInfo() != nil {
+			return apperrors.Invalid(apperrors.CodeInvalidRequest, "only the first message of an upload can carry its info")
+		}
+
+		content.Write(msg.GetChunk())
+		if s.config.FileMaxSize > 0 && int64(content.Len()) > s.config.FileMaxSize {
+			return apperrors.New(apperrors.KindTooLarge, apperrors.CodeFileTooLarge, "%v: the maximum is %d bytes", files.ErrFileTooLarge, s.config.FileMaxSize)
+		}
+	}
+
+	userFile, err := s.userService.UploadUserFile(stream.Context(), info.GetUserId(), request, info.GetContentType(), content.Bytes())
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(toUserFile(userFile))
+}
+
+func (s *userServer) GetUserFile(ctx context.Context, req *usersv1.GetUserFileRequest) (*usersv1.UserFile, error) {
+	userFile, err := s.userService.GetUserFile(ctx, req.GetUserId(), req.GetFileId())
+	if err != nil {
+		return nil, err
+	}
+	return toUserFile(userFile), nil
+}
+
+func (s *userServer) ListUserFiles(ctx context.Context, req *usersv1.ListUserFilesRequest) (*usersv1.ListUserFilesResponse, error) {
+	pageRequest, err := paginationRequest(req.GetPagination())
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := s.userService.ListUserFiles(ctx, req.GetUserId(), fileFilter(req.GetFilter()), pageRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &usersv1.ListUserFilesResponse{PageInfo: toPageInfo(env)}
+	if list, ok := env.Data.(*usersdto.UserFileListResponse); ok {
+		for i := range list.UserFiles {
+			resp.UserFiles = append(resp.UserFiles, toUserFile(&list.UserFiles[i]))
+		}
+	}
+	return resp, nil
+}
+
+func (s *userServer) UpdateUserFile(ctx context.Context, req *usersv1.UpdateUserFileRequest) (*usersv1.UserFile, error) {
+	request := &usersdto.UserFileUpdateRequest{}
+	userFile := req.GetUserFile()
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "filename":
+			filename := userFile.GetFilename()
+			request.Filename = &filename
+		case "description":
+			description := userFile.GetDescription()
+			request.Description = &description
+		case "tags":
+			tags := userFile.GetTags()
+			if tags == nil {
+				tags = []string{}
+			}
+			request.Tags = &tags
+		case "expires_at":
+			if userFile.GetExpiresAt() == nil {
+				request.NoExpiry = true
+				continue
+			}
+			expiresAt := userFile.GetExpiresAt().AsTime()
+			request.ExpiresAt = &expiresAt
+		default:
+			return nil, apperrors.Invalid(apperrors.CodeInvalidRequest, "field %s cannot be updated", path)
+		}
+	}
+	if err := s.validator.Struct(request); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.userService.UpdateUserFile(ctx, req.GetUserId(), req.GetFileId(), request)
+	if err != nil {
+		return nil, err
+	}
+	return toUserFile(updated), nil
+}
+
+func (s *userServer) DeleteUserFile(ctx context.Context, req *usersv1.DeleteUserFileRequest) (*emptypb.Empty, error) {
+	if err := s.userService.DeleteUserFile(ctx, req.GetUserId(), req.GetFileId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *userServer) RestoreUserFile(ctx context.Context, req *usersv1.RestoreUserFileRequest) (*usersv1.UserFile, error) {
+	userFile, err := s.userService.RestoreUserFile(ctx, req.GetUserId(), req.GetFileId())
+	if err != nil {
+		return nil, err
+	}
+	return toUserFile(userFile), nil
+}
+
+func (s *userServer) DownloadUserFile(req *usersv1.DownloadUserFileRequest, stream usersv1.UserService_DownloadUserFileServer) error {
+	file, err := s.userService.DownloadUserFile(stream.Context(), req.GetUserId(), req.GetFileId())
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&usersv1.DownloadUserFileResponse{
+		Data: &usersv1.DownloadUserFileResponse_Info{Info: &usersv1.DownloadUserFileResponse_DownloadInfo{
+			Filename:    file.Filename,
+			ContentType: file.FileType,
+			Size:        int64(len(file.Content)),
+			Sha256:      file.Sha256,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	for content := file.Content; len(content) > 0; {
+		n := len(content)
+		if n > downloadChunkSize {
+			n = downloadChunkSize
+		}
+		err = stream.Send(&usersv1.DownloadUserFileResponse{
+			Data: &usersv1.DownloadUserFileResponse_Chunk{Chunk: content[:n]},
+		})
+		if err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	return nil
+}
+
+// paginationRequest converts the pagination of a request, with the defaults of the
+// REST API for omitted fields.
+func paginationRequest(pagination *usersv1.Pagination) (*dto.PaginationRequest, error) {
+	limit, page, sort := dto.DefaultLimit, dto.DefaultPage, dto.DefaultSort
+	if pagination.GetLimit() > 0 {
+		limit = int(pagination.GetLimit())
+	}
+	if pagination.GetPage() > 0 {
+		page = int(pagination.GetPage())
+	}
+	if pagination.GetSort() != "" {
+		var err error
+		if sort, err = dto.ParseSort(pagination.GetSort()); err != nil {
+			return nil, apperrors.InvalidRequest(err)
+		}
+	}
+	return dto.NewPaginationRequest(limit, page, sort, map[string]string{}, map[string]string{})
+}
+
+func fileRequest(details *usersv1.UserFileDetails) *usersdto.UserFileRequest {
+	request := &usersdto.UserFileRequest{
+		Filename:    details.GetFilename(),
+		Description: details.GetDescription(),
+		Tags:        details.GetTags(),
+	}
+	if details.GetExpiresAt() != nil {
+		expiresAt := details.GetExpiresAt().AsTime()
+		request.ExpiresAt = &expiresAt
+	}
+	return request
+}
+
+func fileFilter(filter *usersv1.UserFileFilter) *usersdto.UserFileFilterRequest {
+	request := &usersdto.UserFileFilterRequest{
+		FileType:  filter.GetFileType(),
+		Title:     filter.GetTitle(),
+		Author:    filter.GetAuthor(),
+		Encrypted: filter.Encrypted,
+	}
+	for _, tag := range filter.GetTags() {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			request.Tags = append(request.Tags, tag)
+		}
+	}
+	if filter.MinPages != nil {
+		minPages := int(filter.GetMinPages())
+		request.MinPages = &minPages
+	}
+	if filter.MaxPages != nil {
+		maxPages := int(filter.GetMaxPages())
+		request.MaxPages = &maxPages
+	}
+	return request
+}
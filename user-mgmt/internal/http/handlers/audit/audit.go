@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	auditdto "github.com/pedromspeixoto/users-api/internal/dto/audit"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
+	"github.com/pedromspeixoto/users-api/internal/http/middlewares"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+type AuditServiceHandler interface {
+	Routes() chi.Router
+}
+
+type auditServiceDeps struct {
+	fx.In
+
+	Logger       *logger.LoggingClient
+	AuditService audit.AuditService
+}
+
+type auditServiceHandler struct {
+	auditServiceDeps
+	logger.Logger
+}
+
+func NewAuditServiceHandler(deps auditServiceDeps) AuditServiceHandler {
+	return &auditServiceHandler{
+		auditServiceDeps: deps,
+		Logger:           deps.Logger.GetLogger(),
+	}
+}
+
+func (h auditServiceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.With(middlewares.Paginate).Get("/", h.ListAuditEvents)
+
+	return r
+}
+
+// ListAuditEvents - Handles audit log
+// @Summary Gets audit events.
+// @Description This API is used to list audit events, newest first
+// @Param limit       query int    false "Limit"
+// @Param page        query int    false "Page"
+// @Param actor       query string false "Actor"
+// @Param action      query string false "Action (e.g. user.create)"
+// @Param target_type query string false "Target type (user or file)"
+// @Param target_id   query string false "Target ID"
+// @Param request_id  query string false "Request ID"
+// @Param from        query string false "Events created at or after (RFC 3339)"
+// @Param to          query string false "Events created at or before (RFC 3339)"
+// @Tags audit
+// @Accept  json
+// @Produce  json
+// @Router /v1/audit [get]
+func (h auditServiceHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	limit := r.Context().Value(middlewares.LimitKey).(int)
+	page := r.Context().Value(middlewares.PageKey).(int)
+	sort := r.Context().Value(middlewares.SortKey).(string)
+	filter := r.Context().Value(middlewares.FilterKey).(map[string]string)
+	search := r.Context().Value(middlewares.SearchKey).(map[string]string)
+
+	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	query := r.URL.Query()
+	eventFilter := &auditdto.AuditEventFilterRequest{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetId:   query.Get("target_id"),
+		RequestId:  query.Get("request_id"),
+	}
+	if eventFilter.From, err = parseTime(query.Get("from")); err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+	if eventFilter.To, err = parseTime(query.Get("to")); err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	env, err := h.auditServiceDeps.AuditService.ListEvents(r.Context(), eventFilter, pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "audit events retrieved", env)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed time %q, should be RFC 3339", value)
+	}
+	return &t, nil
+}
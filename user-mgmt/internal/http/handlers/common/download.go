@@ -0,0 +1,34 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
+)
+
+// Download writes the content of a downloaded user file with its headers.
+func Download(w http.ResponseWriter, r *http.Request, file *usersdto.UserFileDownload) {
+	digest, err := hex.DecodeString(file.Sha256)
+	if err != nil {
+		problem.Write(w, r, apperrors.Internal(err, "unexpected error decoding user file digest"))
+		return
+	}
+
+	// set headers
+	w.Header().Set("Content-Disposition", files.ContentDisposition(file.Filename))
+	w.Header().Set("Content-Type", http.DetectContentType(file.Content))
+	w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(digest))
+	w.Header().Set("ETag", fmt.Sprintf("%q", file.Sha256))
+
+	_, err = w.Write(file.Content)
+	if err != nil {
+		// the status is sent already, only the logs can tell
+		problem.RecordError(r.Context(), err)
+	}
+}
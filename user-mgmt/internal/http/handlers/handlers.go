@@ -1,16 +1,26 @@
 package handlers
 
 import (
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/audit"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/health"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/jobs"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/metrics"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/search"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/shares"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/users"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/webhooks"
 	"go.uber.org/fx"
 )
 
 func ProvideHandlers() fx.Option {
 	return fx.Provide(
+		audit.NewAuditServiceHandler,
 		health.NewHealthServiceHandler,
+		jobs.NewJobServiceHandler,
 		metrics.NewMetricServiceHandler,
+		search.NewSearchServiceHandler,
+		shares.NewShareServiceHandler,
 		users.NewUserServiceHandler,
+		webhooks.NewWebhookServiceHandler,
 	)
 }
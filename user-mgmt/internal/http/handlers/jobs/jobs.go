@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/pedromspeixoto/users-api/internal/domain/jobs"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+type JobServiceHandler interface {
+	Routes() chi.Router
+}
+
+type jobServiceDeps struct {
+	fx.In
+
+	Logger     *logger.LoggingClient
+	JobService jobs.JobService
+}
+
+type jobServiceHandler struct {
+	jobServiceDeps
+	logger.Logger
+}
+
+func NewJobServiceHandler(deps jobServiceDeps) JobServiceHandler {
+	return &jobServiceHandler{
+		jobServiceDeps: deps,
+		Logger:         deps.Logger.GetLogger(),
+	}
+}
+
+func (h jobServiceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/{jobId}", h.GetJob)
+	r.Post("/{jobId}:cancel", h.CancelJob)
+
+	return r
+}
+
+// GetJob - Handles job management
+// @Summary Get a job.
+// @Description This API is used to poll the status, progress, result and error of a background job
+// @Param job_id path string true "Job ID"
+// @Tags jobs
+// @Accept  json
+// @Produce  json
+// @Router /v1/jobs/{job_id} [get]
+func (h jobServiceHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobId := chi.URLParam(r, "jobId")
+
+	job, err := h.JobService.GetJob(r.Context(), jobId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "job retrieved", job)
+}
+
+// CancelJob - Handles job management
+// @Summary Cancel a job.
+// @Description This API is used to cancel a background job. Queued jobs are cancelled right away, running jobs stop at their next checkpoint.
+// @Param job_id path string true "Job ID"
+// @Tags jobs
+// @Accept  json
+// @Produce  json
+// @Router /v1/jobs/{job_id}:cancel [post]
+func (h jobServiceHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobId := chi.URLParam(r, "jobId")
+
+	job, err := h.JobService.CancelJob(r.Context(), jobId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusAccepted, "job cancellation requested", job)
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/search"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
+	"github.com/pedromspeixoto/users-api/internal/http/middlewares"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+type SearchServiceHandler interface {
+	Routes() chi.Router
+}
+
+type searchServiceDeps struct {
+	fx.In
+
+	Logger        *logger.LoggingClient
+	SearchService search.SearchService
+}
+
+type searchServiceHandler struct {
+	searchServiceDeps
+	logger.Logger
+}
+
+func NewSearchServiceHandler(deps searchServiceDeps) SearchServiceHandler {
+	return &searchServiceHandler{
+		searchServiceDeps: deps,
+		Logger:            deps.Logger.GetLogger(),
+	}
+}
+
+func (h searchServiceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.With(middlewares.Paginate).Get("/", h.SearchFiles)
+
+	return r
+}
+
+// SearchFiles - Handles file search
+// @Summary Search files by content.
+// @Description This API is used to search the text of PDF files, best matches first, with snippets where the matching terms are wrapped in <em> tags. Callers identified by X-Actor-Id find their own files.
+// @Param q     query string true  "Search query"
+// @Param limit query int    false "Limit"
+// @Param page  query int    false "Page"
+// @Tags search
+// @Accept  json
+// @Produce  json
+// @Router /v1/files:search [get]
+func (h searchServiceHandler) SearchFiles(w http.ResponseWriter, r *http.Request) {
+	limit := r.Context().Value(middlewares.LimitKey).(int)
+	page := r.Context().Value(middlewares.PageKey).(int)
+	sort := r.Context().Value(middlewares.SortKey).(string)
+	filter := r.Context().Value(middlewares.FilterKey).(map[string]string)
+	search := r.Context().Value(middlewares.SearchKey).(map[string]string)
+
+	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	env, err := h.SearchService.SearchFiles(r.Context(), r.URL.Query().Get("q"), pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "files retrieved", env)
+}
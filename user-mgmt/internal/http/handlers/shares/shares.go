@@ -0,0 +1,64 @@
+package shares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/pedromspeixoto/users-api/internal/domain/shares"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+type ShareServiceHandler interface {
+	Routes() chi.Router
+}
+
+type shareServiceDeps struct {
+	fx.In
+
+	Logger       *logger.LoggingClient
+	ShareService shares.ShareService
+}
+
+type shareServiceHandler struct {
+	shareServiceDeps
+	logger.Logger
+}
+
+func NewShareServiceHandler(deps shareServiceDeps) ShareServiceHandler {
+	return &shareServiceHandler{
+		shareServiceDeps: deps,
+		Logger:           deps.Logger.GetLogger(),
+	}
+}
+
+func (h shareServiceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/{token}", h.DownloadSharedFile)
+
+	return r
+}
+
+// DownloadSharedFile - Handles shared files
+// @Summary Download a shared file.
+// @Description This API is used to download a file through a share link, without API access. Expired, revoked or exhausted links return 410, links with an invalid signature 403.
+// @Param token path string true "Signed share token"
+// @Tags shares
+// @Produce  octet-stream
+// @Router /v1/shared/{token} [get]
+func (h shareServiceHandler) DownloadSharedFile(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	file, err := h.ShareService.DownloadSharedFile(r.Context(), token)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// links may be forwarded, keep the content out of shared caches
+	w.Header().Set("Cache-Control", "private, no-store")
+	common.Download(w, r, file)
+}
@@ -3,31 +3,45 @@ package users
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
 	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/shares"
 	"github.com/pedromspeixoto/users-api/internal/domain/users"
 	"github.com/pedromspeixoto/users-api/internal/dto"
+	sharesdto "github.com/pedromspeixoto/users-api/internal/dto/shares"
 	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
 	"github.com/pedromspeixoto/users-api/internal/http/middlewares"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/files"
 	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
 	"go.uber.org/fx"
 )
 
 type UserServiceHandler interface {
 	Routes() chi.Router
+	// ArchiveUserFiles is routed apart from Routes, archives are streamed for longer
+	// than the timeout of other requests.
+	ArchiveUserFiles(w http.ResponseWriter, r *http.Request)
 }
 
 type userServiceDeps struct {
 	fx.In
 
-	Config      *config.Config
-	Logger      *logger.LoggingClient
-	Validator   *validator.Validate
-	UserService users.UserService
+	Config       *config.Config
+	Logger       *logger.LoggingClient
+	Validator    *validator.Validate
+	UserService  users.UserService
+	JobService   jobs.JobService
+	ShareService shares.ShareService
 }
 
 type userServiceHandler struct {
@@ -51,13 +65,31 @@ func (h userServiceHandler) Routes() chi.Router {
 	r.Put("/", h.CreateUser)
 	r.Get("/{userId}", h.GetUser)
 	r.Delete("/{userId}", h.DeleteUser)
+	r.Post("/{userId}:verify", h.VerifyUser)
+	r.Post("/{userId}:restore", h.RestoreUser)
+	r.Get("/{userId}/usage", h.GetUserUsage)
 
 	// user files
 	r.With(middlewares.Paginate).Get("/{userId}/files", h.ListUserFiles)
 	r.Post("/{userId}/files", h.CreateUserFile)
 	r.Get("/{userId}/files/{fileId}", h.GetUserFile)
+	r.Patch("/{userId}/files/{fileId}", h.UpdateUserFile)
 	r.Delete("/{userId}/files/{fileId}", h.DeleteUserFile)
+	r.Post("/{userId}/files/{fileId}:restore", h.RestoreUserFile)
+	r.Post("/{userId}/files/{fileId}:purge", h.PurgeUserFile)
 	r.Get("/{userId}/files/{fileId}/download", h.DownloadUserFile)
+	r.Get("/{userId}/files/{fileId}/thumbnail", h.GetUserFileThumbnail)
+
+	// user file versions
+	r.With(middlewares.Paginate).Get("/{userId}/files/{fileId}/versions", h.ListUserFileVersions)
+	r.Post("/{userId}/files/{fileId}/versions", h.CreateUserFileVersion)
+	r.Get("/{userId}/files/{fileId}/versions/{version}/download", h.DownloadUserFileVersion)
+	r.Post("/{userId}/files/{fileId}/versions/{version}:restore", h.RestoreUserFileVersion)
+
+	// user file shares
+	r.Post("/{userId}/files/{fileId}:share", h.ShareUserFile)
+	r.With(middlewares.Paginate).Get("/{userId}/files/{fileId}/shares", h.ListUserFileShares)
+	r.Delete("/{userId}/files/{fileId}/shares/{shareId}", h.RevokeUserFileShare)
 
 	return r
 }
@@ -80,22 +112,24 @@ func (h userServiceHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 
 	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
 	if err != nil {
-		common.Err(w, http.StatusBadRequest, err.Error())
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
 	}
 
-	statusCode, env, err := h.userServiceDeps.UserService.ListUsers(r.Context(), pageRequest)
+	env, err := h.userServiceDeps.UserService.ListUsers(r.Context(), pageRequest)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "users retrieved", env)
+	common.Json(w, http.StatusOK, "users retrieved", env)
 }
 
 // CreateUser - Handles user management
 // @Summary Create a new user.
 // @Description This API is used to create a new user
 // @Param request body usersdto.UserRequest true "User Payload"
+// @Param Idempotency-Key header string false "Makes retries of the request safe, the original response is replayed"
 // @Tags users
 // @Accept  json
 // @Produce  json
@@ -104,23 +138,23 @@ func (h userServiceHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	user := usersdto.UserRequest{}
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
-		common.Err(w, http.StatusInternalServerError, err.Error())
+		problem.Write(w, r, apperrors.MalformedBody(err))
 		return
 	}
 
 	err = h.Validator.Struct(user)
 	if err != nil {
-		common.Err(w, http.StatusBadRequest, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	statusCode, userResponse, err := h.UserService.CreateUser(r.Context(), &user)
+	userResponse, err := h.UserService.CreateUser(r.Context(), &user)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "new user created", userResponse)
+	common.Json(w, http.StatusCreated, "new user created", userResponse)
 }
 
 // GetUser - Handles user management
@@ -133,13 +167,32 @@ func (h userServiceHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 // @Router /v1/users/{user_id} [get]
 func (h userServiceHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "userId")
-	statusCode, user, err := h.userServiceDeps.UserService.GetUser(r.Context(), userId)
+	user, err := h.userServiceDeps.UserService.GetUser(r.Context(), userId)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "user retrieved", user)
+	common.Json(w, http.StatusOK, "user retrieved", user)
+}
+
+// GetUserUsage - Handles user management
+// @Summary Get the storage usage of a user.
+// @Description This API is used to get the number of files and bytes stored by a user against its quotas. A zero limit means unlimited.
+// @Param user_id path string true "User ID"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/usage [get]
+func (h userServiceHandler) GetUserUsage(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	usage, err := h.userServiceDeps.UserService.GetUserUsage(r.Context(), userId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user usage retrieved", usage)
 }
 
 // DeleteUser - Handles users mgmt
@@ -152,21 +205,81 @@ func (h userServiceHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Router /v1/users/{user_id} [delete]
 func (h userServiceHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "userId")
-	statusCode, err := h.userServiceDeps.UserService.DeleteUser(r.Context(), userId)
+	err := h.userServiceDeps.UserService.DeleteUser(r.Context(), userId)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "", nil)
+	common.Json(w, http.StatusOK, "", nil)
+}
+
+// RestoreUser - Handles users mgmt
+// @Summary Restore a user.
+// @Description This API is used to restore a deleted user
+// @Param user_id path string true "User ID"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}:restore [post]
+func (h userServiceHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	user, err := h.userServiceDeps.UserService.RestoreUser(r.Context(), userId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user restored", user)
+}
+
+// VerifyUser - Handles users mgmt
+// @Summary Verify a user email.
+// @Description This API is used to verify a user email with the token sent on user creation
+// @Param user_id path string true "User ID"
+// @Param request body usersdto.VerifyUserRequest true "Verification Payload"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}:verify [post]
+func (h userServiceHandler) VerifyUser(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+
+	request := usersdto.VerifyUserRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	user, err := h.userServiceDeps.UserService.VerifyUser(r.Context(), userId, &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user email verified", user)
 }
 
 // ListUserFiles - Handles user files management
 // @Summary Gets all user files.
-// @Description This API is used to list all user files
-// @Param user_id path string true "User ID"
-// @Param limit query int false "Limit"
-// @Param page  query int false "Page"
+// @Description This API is used to list all user files, optionally filtered by type, tags or PDF metadata. Expired files are left out.
+// @Param user_id   path  string true  "User ID"
+// @Param limit     query int    false "Limit"
+// @Param page      query int    false "Page"
+// @Param file_type query string false "File type (e.g. application/pdf)"
+// @Param min_pages query int    false "Minimum page count"
+// @Param max_pages query int    false "Maximum page count"
+// @Param title     query string false "Title contains"
+// @Param author    query string false "Author contains"
+// @Param encrypted query bool   false "Encrypted documents only, or unencrypted only"
+// @Param tag       query []string false "Tag the files must have, repeat for several" collectionFormat(multi)
 // @Tags users
 // @Accept  json
 // @Produce  json
@@ -181,22 +294,74 @@ func (h userServiceHandler) ListUserFiles(w http.ResponseWriter, r *http.Request
 
 	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
 	if err != nil {
-		common.Err(w, http.StatusBadRequest, err.Error())
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	fileFilter, err := parseFileFilter(r)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	userFiles, err := h.userServiceDeps.UserService.ListUserFiles(r.Context(), userId, fileFilter, pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user files retrieved", userFiles)
+}
+
+// ArchiveUserFiles - Handles user files management
+// @Summary Download user files as a ZIP archive.
+// @Description This API is used to download all the files of a user, or the ones matching the filters, as a ZIP archive generated on the fly. The archive holds a manifest.json with the metadata of every file; quarantined files are only listed in the manifest.
+// @Param user_id   path  string true  "User ID"
+// @Param file_type query string false "File type (e.g. application/pdf)"
+// @Param min_pages query int    false "Minimum page count"
+// @Param max_pages query int    false "Maximum page count"
+// @Param title     query string false "Title contains"
+// @Param author    query string false "Author contains"
+// @Param encrypted query bool   false "Encrypted documents only, or unencrypted only"
+// @Param tag       query []string false "Tag the files must have, repeat for several" collectionFormat(multi)
+// @Tags users
+// @Produce  application/zip
+// @Router /v1/users/{user_id}/files:archive [get]
+func (h userServiceHandler) ArchiveUserFiles(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+
+	fileFilter, err := parseFileFilter(r)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
 	}
 
-	statusCode, userFiles, err := h.userServiceDeps.UserService.ListUserFiles(r.Context(), userId, pageRequest)
+	archive, err := h.userServiceDeps.UserService.ArchiveUserFiles(r.Context(), userId, fileFilter)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "user files retrieved", userFiles)
+	// set headers
+	w.Header().Set("Content-Type", files.MimeTypeZIP)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.Filename))
+	w.WriteHeader(http.StatusOK)
+
+	// the status is sent already, the connection is reset so the client does not
+	// take a truncated archive for a complete one
+	if err = archive.Write(w); err != nil {
+		h.Errorf("failed to write archive of user %s: %v", userId, err)
+		panic(http.ErrAbortHandler)
+	}
 }
 
 // CreateUserFile - Handles user files management
 // @Summary Create a new user file.
-// @Description This API is used to create a new user file
+// @Description This API is used to create a new user file. Without a body the file is fetched from the file serving service, with a url it is imported from that url, which must be allowed and resolve to a public address. The filename defaults to the last segment of the url; tags are stored lowercase. Files are deleted at expires_at, which defaults to the retention configured for their type. With async=true a job is queued instead and 202 is returned with its location. Files over the maximum size are rejected with 413, types that are not allowed with 415 and files over the user quotas with 507.
 // @Param user_id path string true "User ID"
+// @Param async query bool false "Process the file in the background"
+// @Param request body usersdto.UserFileRequest false "User File Payload"
+// @Param Idempotency-Key header string false "Makes retries of the request safe, the original response is replayed"
 // @Tags users
 // @Accept  json
 // @Produce  json
@@ -204,13 +369,39 @@ func (h userServiceHandler) ListUserFiles(w http.ResponseWriter, r *http.Request
 func (h userServiceHandler) CreateUserFile(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "userId")
 
-	statusCode, userFile, err := h.userServiceDeps.UserService.CreateUserFile(r.Context(), userId)
+	// the body is optional
+	request := usersdto.UserFileRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		job, err := h.userServiceDeps.JobService.CreateFileJob(r.Context(), userId, &request)
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/v1/jobs/"+job.JobId)
+		common.Json(w, http.StatusAccepted, "new user file job queued", job)
+		return
+	}
+
+	userFile, err := h.userServiceDeps.UserService.CreateUserFile(r.Context(), userId, &request)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "new user file created", userFile)
+	common.Json(w, http.StatusCreated, "new user file created", userFile)
 }
 
 // GetUserFile - Handles user files management
@@ -226,13 +417,49 @@ func (h userServiceHandler) GetUserFile(w http.ResponseWriter, r *http.Request)
 	userId := chi.URLParam(r, "userId")
 	fileId := chi.URLParam(r, "fileId")
 
-	statusCode, userFile, err := h.userServiceDeps.UserService.GetUserFile(r.Context(), userId, fileId)
+	userFile, err := h.userServiceDeps.UserService.GetUserFile(r.Context(), userId, fileId)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "user file retrieved", userFile)
+	common.Json(w, http.StatusOK, "user file retrieved", userFile)
+}
+
+// UpdateUserFile - Handles user files management
+// @Summary Update a user file.
+// @Description This API is used to change the filename, description and tags of a user file. Omitted fields are kept, an empty filename or description removes it and an empty list of tags removes them all and no_expiry removes the expiry. Deleted files can not be updated.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param request body usersdto.UserFileUpdateRequest true "User File Update Payload"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id} [patch]
+func (h userServiceHandler) UpdateUserFile(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	request := usersdto.UserFileUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	userFile, err := h.userServiceDeps.UserService.UpdateUserFile(r.Context(), userId, fileId, &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file updated", userFile)
 }
 
 // DeleteUserFile - Handles user files management
@@ -248,18 +475,62 @@ func (h userServiceHandler) DeleteUserFile(w http.ResponseWriter, r *http.Reques
 	userId := chi.URLParam(r, "userId")
 	fileId := chi.URLParam(r, "fileId")
 
-	statusCode, err := h.userServiceDeps.UserService.DeleteUserFile(r.Context(), userId, fileId)
+	err := h.userServiceDeps.UserService.DeleteUserFile(r.Context(), userId, fileId)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
-	common.Json(w, statusCode, "", nil)
+	common.Json(w, http.StatusOK, "", nil)
+}
+
+// PurgeUserFile - Handles user files management
+// @Summary Permanently delete a user file.
+// @Description This API is used to permanently delete a user file, deleted or not. It cannot be restored afterwards. Content shared with other files is kept until its last file is purged.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}:purge [post]
+func (h userServiceHandler) PurgeUserFile(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	err := h.userServiceDeps.UserService.PurgeUserFile(r.Context(), userId, fileId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "", nil)
+}
+
+// RestoreUserFile - Handles user files management
+// @Summary Restore a user file.
+// @Description This API is used to restore a deleted user file
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}:restore [post]
+func (h userServiceHandler) RestoreUserFile(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	userFile, err := h.userServiceDeps.UserService.RestoreUserFile(r.Context(), userId, fileId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file restored", userFile)
 }
 
 // DownloadUserFile - Handles user files management
 // @Summary Download a user file.
-// @Description This API is used to download a user file
+// @Description This API is used to download a user file. The Digest header carries the SHA-256 digest of the content.
 // @Param user_id path string true "User ID"
 // @Param file_id path string true "File ID"
 // @Tags users
@@ -270,19 +541,324 @@ func (h userServiceHandler) DownloadUserFile(w http.ResponseWriter, r *http.Requ
 	userId := chi.URLParam(r, "userId")
 	fileId := chi.URLParam(r, "fileId")
 
-	statusCode, file, fileType, err := h.userServiceDeps.UserService.DownloadUserFile(r.Context(), userId, fileId)
+	file, err := h.userServiceDeps.UserService.DownloadUserFile(r.Context(), userId, fileId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Download(w, r, file)
+}
+
+// GetUserFileThumbnail - Handles user files management
+// @Summary Get a user file thumbnail.
+// @Description This API is used to get a PNG preview of an image or PDF user file that fits in a size x size box. Thumbnails are generated on first request and cached.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param size query int false "Size in pixels, one of the configured thumbnail sizes"
+// @Tags users
+// @Produce  png
+// @Router /v1/users/{user_id}/files/{file_id}/thumbnail [get]
+func (h userServiceHandler) GetUserFileThumbnail(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	size := h.Config.ThumbnailDefaultSize
+	if value := r.URL.Query().Get("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidThumbnailSize, "invalid thumbnail size %q", value))
+			return
+		}
+		size = parsed
+	}
+
+	thumbnail, err := h.userServiceDeps.UserService.GetUserFileThumbnail(r.Context(), userId, fileId, size)
 	if err != nil {
-		common.Err(w, statusCode, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
 
 	// set headers
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=filename.%s", fileType))
-	w.Header().Set("Content-Type", http.DetectContentType(file))
+	w.Header().Set("Content-Type", files.ThumbnailContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+
+	_, err = w.Write(thumbnail)
+	if err != nil {
+		h.Errorf("failed to write thumbnail of user file %s: %v", fileId, err)
+	}
+}
+
+// ListUserFileVersions - Handles user files management
+// @Summary Gets all versions of a user file.
+// @Description This API is used to list the versions of a user file, newest first
+// @Param user_id path  string true  "User ID"
+// @Param file_id path  string true  "File ID"
+// @Param limit   query int    false "Limit"
+// @Param page    query int    false "Page"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/versions [get]
+func (h userServiceHandler) ListUserFileVersions(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+	limit := r.Context().Value(middlewares.LimitKey).(int)
+	page := r.Context().Value(middlewares.PageKey).(int)
+	sort := r.Context().Value(middlewares.SortKey).(string)
+	filter := r.Context().Value(middlewares.FilterKey).(map[string]string)
+	search := r.Context().Value(middlewares.SearchKey).(map[string]string)
+
+	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	versions, err := h.userServiceDeps.UserService.ListUserFileVersions(r.Context(), userId, fileId, pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file versions retrieved", versions)
+}
+
+// CreateUserFileVersion - Handles user files management
+// @Summary Upload a new version of a user file.
+// @Description This API is used to upload a new version of a user file, fetched like a new user file, which becomes the current version. Only the configured maximum number of versions is retained, the oldest ones are deleted. Every retained version counts towards the user quotas. The filename, description and tags of the request are ignored, they are changed with a PATCH of the file.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param request body usersdto.UserFileRequest false "User File Payload"
+// @Param Idempotency-Key header string false "Makes retries of the request safe, the original response is replayed"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/versions [post]
+func (h userServiceHandler) CreateUserFileVersion(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	// the body is optional
+	request := usersdto.UserFileRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	userFile, err := h.userServiceDeps.UserService.CreateUserFileVersion(r.Context(), userId, fileId, &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusCreated, "new user file version created", userFile)
+}
+
+// DownloadUserFileVersion - Handles user files management
+// @Summary Download a version of a user file.
+// @Description This API is used to download a version of a user file. The Digest header carries the SHA-256 digest of the content.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param version path int    true "Version"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/versions/{version}/download [get]
+func (h userServiceHandler) DownloadUserFileVersion(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidRequest, "invalid version %q", chi.URLParam(r, "version")))
+		return
+	}
+
+	file, err := h.userServiceDeps.UserService.DownloadUserFileVersion(r.Context(), userId, fileId, version)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Download(w, r, file)
+}
+
+// RestoreUserFileVersion - Handles user files management
+// @Summary Restore a version of a user file.
+// @Description This API is used to make an older version of a user file the current one. A copy of it is stored as a new version, so the history is kept.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param version path int    true "Version"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/versions/{version}:restore [post]
+func (h userServiceHandler) RestoreUserFileVersion(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidRequest, "invalid version %q", chi.URLParam(r, "version")))
+		return
+	}
+
+	userFile, err := h.userServiceDeps.UserService.RestoreUserFileVersion(r.Context(), userId, fileId, version)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file version restored", userFile)
+}
 
-	_, err = w.Write(file)
+// ShareUserFile - Handles user files management
+// @Summary Share a user file.
+// @Description This API is used to create a signed link to a user file that can be downloaded without API access, until it expires, its maximum number of downloads is reached or it is revoked. The link always serves the current version of the file and is only returned once.
+// @Param user_id path string true "User ID"
+// @Param file_id path string true "File ID"
+// @Param request body sharesdto.ShareRequest false "Share Payload"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}:share [post]
+func (h userServiceHandler) ShareUserFile(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+
+	// the body is optional
+	request := sharesdto.ShareRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
 	if err != nil {
-		common.Err(w, http.StatusInternalServerError, err.Error())
+		problem.Write(w, r, err)
 		return
 	}
+
+	share, err := h.userServiceDeps.ShareService.ShareUserFile(r.Context(), userId, fileId, &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusCreated, "user file shared", share)
+}
+
+// ListUserFileShares - Handles user files management
+// @Summary Gets all shares of a user file.
+// @Description This API is used to list the shares of a user file, newest first, including expired and revoked ones
+// @Param user_id path  string true  "User ID"
+// @Param file_id path  string true  "File ID"
+// @Param limit   query int    false "Limit"
+// @Param page    query int    false "Page"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/shares [get]
+func (h userServiceHandler) ListUserFileShares(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+	limit := r.Context().Value(middlewares.LimitKey).(int)
+	page := r.Context().Value(middlewares.PageKey).(int)
+	sort := r.Context().Value(middlewares.SortKey).(string)
+	filter := r.Context().Value(middlewares.FilterKey).(map[string]string)
+	search := r.Context().Value(middlewares.SearchKey).(map[string]string)
+
+	pageRequest, err := dto.NewPaginationRequest(limit, page, sort, filter, search)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	shares, err := h.userServiceDeps.ShareService.ListShares(r.Context(), userId, fileId, pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file shares retrieved", shares)
+}
+
+// RevokeUserFileShare - Handles user files management
+// @Summary Revoke a share of a user file.
+// @Description This API is used to revoke a share of a user file, its link stops working right away
+// @Param user_id  path string true "User ID"
+// @Param file_id  path string true "File ID"
+// @Param share_id path string true "Share ID"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Router /v1/users/{user_id}/files/{file_id}/shares/{share_id} [delete]
+func (h userServiceHandler) RevokeUserFileShare(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "userId")
+	fileId := chi.URLParam(r, "fileId")
+	shareId := chi.URLParam(r, "shareId")
+
+	share, err := h.userServiceDeps.ShareService.RevokeShare(r.Context(), userId, fileId, shareId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "user file share revoked", share)
+}
+
+// parseFileFilter parses the user file filters of the query string.
+func parseFileFilter(r *http.Request) (*usersdto.UserFileFilterRequest, error) {
+	query := r.URL.Query()
+	fileFilter := &usersdto.UserFileFilterRequest{
+		FileType: query.Get("file_type"),
+		Title:    query.Get("title"),
+		Author:   query.Get("author"),
+	}
+	for _, tag := range query["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			fileFilter.Tags = append(fileFilter.Tags, tag)
+		}
+	}
+
+	var err error
+	if fileFilter.MinPages, err = parseInt(query.Get("min_pages")); err != nil {
+		return nil, err
+	}
+	if fileFilter.MaxPages, err = parseInt(query.Get("max_pages")); err != nil {
+		return nil, err
+	}
+	if fileFilter.Encrypted, err = parseBool(query.Get("encrypted")); err != nil {
+		return nil, err
+	}
+	return fileFilter, nil
+}
+
+func parseInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed integer %q", value)
+	}
+	return &i, nil
+}
+
+func parseBool(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed boolean %q", value)
+	}
+	return &b, nil
 }
@@ -0,0 +1,276 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	webhooksdto "github.com/pedromspeixoto/users-api/internal/dto/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/common"
+	"github.com/pedromspeixoto/users-api/internal/http/middlewares"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+type WebhookServiceHandler interface {
+	Routes() chi.Router
+}
+
+type webhookServiceDeps struct {
+	fx.In
+
+	Logger         *logger.LoggingClient
+	Validator      *validator.Validate
+	WebhookService webhooks.WebhookService
+}
+
+type webhookServiceHandler struct {
+	webhookServiceDeps
+	logger.Logger
+}
+
+func NewWebhookServiceHandler(deps webhookServiceDeps) WebhookServiceHandler {
+	return &webhookServiceHandler{
+		webhookServiceDeps: deps,
+		Logger:             deps.Logger.GetLogger(),
+	}
+}
+
+func (h webhookServiceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	// subscriptions
+	r.With(middlewares.Paginate).Get("/", h.ListWebhooks)
+	r.Post("/", h.CreateWebhook)
+	r.Get("/{webhookId}", h.GetWebhook)
+	r.Patch("/{webhookId}", h.UpdateWebhook)
+	r.Delete("/{webhookId}", h.DeleteWebhook)
+
+	// deliveries
+	r.With(middlewares.Paginate).Get("/dead-letters", h.ListDeadLetters)
+	r.With(middlewares.Paginate).Get("/{webhookId}/deliveries", h.ListDeliveries)
+	r.Post("/{webhookId}/deliveries/{deliveryId}:retry", h.RetryDelivery)
+
+	return r
+}
+
+// ListWebhooks - Handles webhook management
+// @Summary Gets all webhook subscriptions.
+// @Description This API is used to list all webhook subscriptions
+// @Param limit query int false "Limit"
+// @Param page  query int false "Page"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks [get]
+func (h webhookServiceHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	pageRequest, err := paginationRequest(r)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	env, err := h.WebhookService.ListWebhooks(r.Context(), pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "webhooks retrieved", env)
+}
+
+// CreateWebhook - Handles webhook management
+// @Summary Create a new webhook subscription.
+// @Description This API is used to subscribe a url to events. Payloads are signed with HMAC-SHA256 in the X-Webhook-Signature header as "t=<unix timestamp>,v1=<hex signature of timestamp.body>". The secret is only returned on creation.
+// @Param request body webhooksdto.WebhookRequest true "Webhook Payload"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks [post]
+func (h webhookServiceHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	request := webhooksdto.WebhookRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	webhook, err := h.WebhookService.CreateWebhook(r.Context(), &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusCreated, "new webhook created", webhook)
+}
+
+// GetWebhook - Handles webhook management
+// @Summary Get a webhook subscription.
+// @Description This API is used to get a webhook subscription by id
+// @Param webhook_id path string true "Webhook ID"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/{webhook_id} [get]
+func (h webhookServiceHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+
+	webhook, err := h.WebhookService.GetWebhook(r.Context(), webhookId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "webhook retrieved", webhook)
+}
+
+// UpdateWebhook - Handles webhook management
+// @Summary Update a webhook subscription.
+// @Description This API is used to update the url, event types or active state of a webhook subscription
+// @Param webhook_id path string true "Webhook ID"
+// @Param request body webhooksdto.WebhookUpdateRequest true "Webhook Payload"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/{webhook_id} [patch]
+func (h webhookServiceHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+
+	request := webhooksdto.WebhookUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		problem.Write(w, r, apperrors.MalformedBody(err))
+		return
+	}
+
+	err = h.Validator.Struct(request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	webhook, err := h.WebhookService.UpdateWebhook(r.Context(), webhookId, &request)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "webhook updated", webhook)
+}
+
+// DeleteWebhook - Handles webhook management
+// @Summary Delete a webhook subscription.
+// @Description This API is used to delete a webhook subscription
+// @Param webhook_id path string true "Webhook ID"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/{webhook_id} [delete]
+func (h webhookServiceHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+
+	err := h.WebhookService.DeleteWebhook(r.Context(), webhookId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "", nil)
+}
+
+// ListDeliveries - Handles webhook management
+// @Summary Gets the delivery history of a webhook subscription.
+// @Description This API is used to list the deliveries of a webhook subscription, newest first
+// @Param webhook_id path  string true  "Webhook ID"
+// @Param status     query string false "Status (pending, succeeded or dead)"
+// @Param limit      query int    false "Limit"
+// @Param page       query int    false "Page"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/{webhook_id}/deliveries [get]
+func (h webhookServiceHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+
+	pageRequest, err := paginationRequest(r)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	env, err := h.WebhookService.ListDeliveries(r.Context(), webhookId, r.URL.Query().Get("status"), pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "webhook deliveries retrieved", env)
+}
+
+// ListDeadLetters - Handles webhook management
+// @Summary Gets dead webhook deliveries.
+// @Description This API is used to list the deliveries of all webhook subscriptions that exhausted their attempts
+// @Param limit query int false "Limit"
+// @Param page  query int false "Page"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/dead-letters [get]
+func (h webhookServiceHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	pageRequest, err := paginationRequest(r)
+	if err != nil {
+		problem.Write(w, r, apperrors.InvalidRequest(err))
+		return
+	}
+
+	env, err := h.WebhookService.ListDeadLetters(r.Context(), pageRequest)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "dead webhook deliveries retrieved", env)
+}
+
+// RetryDelivery - Handles webhook management
+// @Summary Retry a dead webhook delivery.
+// @Description This API is used to schedule a dead webhook delivery for a new round of attempts
+// @Param webhook_id  path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Router /v1/webhooks/{webhook_id}/deliveries/{delivery_id}:retry [post]
+func (h webhookServiceHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	webhookId := chi.URLParam(r, "webhookId")
+	deliveryId := chi.URLParam(r, "deliveryId")
+
+	delivery, err := h.WebhookService.RetryDelivery(r.Context(), webhookId, deliveryId)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	common.Json(w, http.StatusOK, "webhook delivery scheduled", delivery)
+}
+
+func paginationRequest(r *http.Request) (*dto.PaginationRequest, error) {
+	limit := r.Context().Value(middlewares.LimitKey).(int)
+	page := r.Context().Value(middlewares.PageKey).(int)
+	sort := r.Context().Value(middlewares.SortKey).(string)
+	filter := r.Context().Value(middlewares.FilterKey).(map[string]string)
+	search := r.Context().Value(middlewares.SearchKey).(map[string]string)
+
+	return dto.NewPaginationRequest(limit, page, sort, filter, search)
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+)
+
+// AuditContext is a middleware that stores the actor, request id and client ip
+// of each request in its context so that the domain can record audit events.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIp, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIp = r.RemoteAddr
+		}
+
+		ctx := audit.WithMetadata(r.Context(), audit.Metadata{
+			Actor:     r.Header.Get(audit.ActorHeader),
+			RequestId: middleware.GetReqID(r.Context()),
+			ClientIp:  clientIp,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,183 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/pedromspeixoto/users-api/internal/data/models/idempotency"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	"github.com/pedromspeixoto/users-api/internal/pkg/audit"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyCleanupEvery   = time.Minute
+	maxIdempotentRequestSize  = 1 << 20
+	maxIdempotentResponseSize = 1 << 20
+)
+
+var errKeyInUse = apperrors.Conflict(apperrors.CodeIdempotencyKeyInUse, "a request with this idempotency key is already being processed")
+
+// Idempotency is a middleware that makes POST requests sent with an Idempotency-Key
+// header safe to retry. The first response for a key is stored for ttl and replayed
+// for retries with the same body, reusing the key with a different request returns
+// 422 and retrying while the first request is still running returns 409. Server
+// errors and panics are not stored so that they can be retried. Bodies are limited
+// to 1MiB since they are read in memory.
+func Idempotency(repository idempotency.IdempotencyKeyRepository, ttl time.Duration, logger logger.Logger) func(http.Handler) http.Handler {
+	var (
+		mu          sync.Mutex
+		lastCleanup time.Time
+	)
+	cleanup := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(lastCleanup) < idempotencyCleanupEvery {
+			return
+		}
+		lastCleanup = time.Now()
+		go func() {
+			if err := repository.DeleteExpired(); err != nil {
+				logger.Errorf("failed to delete expired idempotency keys: %v", err)
+			}
+		}()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				problem.Write(w, r, apperrors.Invalid(apperrors.CodeIdempotencyKeyInvalid, "idempotency key must be at most %d characters", maxIdempotencyKeyLength))
+				return
+			}
+			cleanup()
+
+			// the body is held in memory to fingerprint the request
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				problem.Write(w, r, apperrors.New(apperrors.KindTooLarge, apperrors.CodeRequestTooLarge, "request body must be at most %d bytes", maxBytesErr.Limit))
+				return
+			}
+			if err != nil {
+				problem.Write(w, r, apperrors.MalformedBody(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			actor := audit.MetadataFromContext(r.Context()).Actor
+			fingerprint := requestFingerprint(r, body)
+
+			stored, err := repository.Get(actor, key)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				problem.Write(w, r, apperrors.Internal(err, "unexpected error fetching idempotency key"))
+				return
+			}
+			if stored != nil && stored.ExpiresAt.Before(time.Now()) {
+				if err = repository.Delete(stored); err != nil {
+					problem.Write(w, r, apperrors.Internal(err, "unexpected error deleting expired idempotency key"))
+					return
+				}
+				stored = nil
+			}
+			if stored != nil {
+				replay(w, r, stored, fingerprint)
+				return
+			}
+
+			record := &idempotency.IdempotencyKey{
+				Actor:          actor,
+				IdempotencyKey: key,
+				Fingerprint:    fingerprint,
+				Status:         idempotency.StatusProcessing,
+				ExpiresAt:      time.Now().Add(ttl),
+			}
+			err = repository.Create(record)
+			if errors.Is(err, idempotency.ErrKeyExists) {
+				// lost the race against a concurrent request with the same key
+				problem.Write(w, r, errKeyInUse)
+				return
+			}
+			if err != nil {
+				problem.Write(w, r, apperrors.Internal(err, "unexpected error storing idempotency key"))
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			response := &bytes.Buffer{}
+			ww.Tee(response)
+
+			defer func() {
+				// a panic is recovered further up the chain, its response is an
+				// error that must not be replayed
+				p := recover()
+				status := ww.Status()
+				if p != nil || status == 0 || status >= http.StatusInternalServerError || response.Len() > maxIdempotentResponseSize {
+					if err := repository.Delete(record); err != nil {
+						logger.Errorf("failed to release idempotency key %s: %v", key, err)
+					}
+					if p != nil {
+						panic(p)
+					}
+					return
+				}
+
+				contentType := ww.Header().Get("Content-Type")
+				record.Status = idempotency.StatusCompleted
+				record.ResponseStatus = &status
+				record.ResponseContentType = &contentType
+				record.ResponseBody = response.Bytes()
+				if err := repository.Update(record); err != nil {
+					logger.Errorf("failed to store response of idempotency key %s: %v", key, err)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, stored *idempotency.IdempotencyKey, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		problem.Write(w, r, apperrors.New(apperrors.KindUnprocessable, apperrors.CodeIdempotencyKeyMismatch, "idempotency key was already used with a different request"))
+		return
+	}
+	if stored.Status != idempotency.StatusCompleted {
+		problem.Write(w, r, errKeyInUse)
+		return
+	}
+
+	if stored.ResponseContentType != nil && *stored.ResponseContentType != "" {
+		w.Header().Set("Content-Type", *stored.ResponseContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(*stored.ResponseStatus)
+	_, _ = w.Write(stored.ResponseBody)
+}
+
+// requestFingerprint identifies a request by its method, path, query and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -6,6 +6,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
 )
 
 const (
@@ -16,19 +20,13 @@ const (
 	SearchKey string = "search"
 )
 
-const (
-	DefaultLimit int    = 10
-	DefaultPage  int    = 1
-	DefaultSort  string = "created_at asc"
-)
-
 func Paginate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		// defaults
-		limit := DefaultLimit
-		page := DefaultPage
-		sort := DefaultSort
+		limit := dto.DefaultLimit
+		page := dto.DefaultPage
+		sort := dto.DefaultSort
 		filter := map[string]string{}
 		search := map[string]string{}
 
@@ -43,9 +41,9 @@ func Paginate(next http.Handler) http.Handler {
 				page, _ = strconv.Atoi(queryValue)
 				break
 			case "sort":
-				formattedSort, err := validateSort(queryValue)
+				formattedSort, err := dto.ParseSort(queryValue)
 				if err != nil {
-					w.WriteHeader(http.StatusBadRequest)
+					problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidRequest, "%v", err))
 					return
 				}
 				sort = formattedSort
@@ -53,7 +51,7 @@ func Paginate(next http.Handler) http.Handler {
 			case "filter":
 				formattedFilter, err := validateFilter(queryValue)
 				if err != nil {
-					w.WriteHeader(http.StatusBadRequest)
+					problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidRequest, "%v", err))
 					return
 				}
 				filter = formattedFilter
@@ -61,7 +59,7 @@ func Paginate(next http.Handler) http.Handler {
 			case "search":
 				formatedSearch, err := validateSearch(queryValue)
 				if err != nil {
-					w.WriteHeader(http.StatusBadRequest)
+					problem.Write(w, r, apperrors.Invalid(apperrors.CodeInvalidRequest, "%v", err))
 					return
 				}
 				search = formatedSearch
@@ -99,17 +97,3 @@ func validateSearch(filter string) (map[string]string, error) {
 	field, value := splits[0], splits[1]
 	return map[string]string{field: value}, nil
 }
-
-func validateSort(sort string) (string, error) {
-	splits := strings.Split(sort, ".")
-	if len(splits) != 2 {
-		return "", fmt.Errorf("malformed sort query, should be field.orderdirection")
-	}
-
-	field, order := splits[0], splits[1]
-	if order != "desc" && order != "asc" {
-		return "", fmt.Errorf("malformed order in sort query, should be asc or desc")
-	}
-
-	return fmt.Sprintf("%s %s", field, strings.ToUpper(order)), nil
-}
@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP is a middleware that sets the remote address of requests sent by one of the
+// trusted proxies, ips or CIDR ranges, to the address of the client they forwarded.
+// It is the rightmost address of X-Forwarded-For that is not a trusted proxy, or
+// X-Real-IP. Both headers are ignored on requests sent by anyone else, since clients
+// can set them to any address.
+func RealIP(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	networks := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(networks) > 0 {
+				if clientIp := forwardedIp(r, networks); clientIp != "" {
+					r.RemoteAddr = clientIp
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// forwardedIp returns the client address forwarded by a trusted proxy, or an empty
+// string when the request was not sent by one or holds no valid address.
+func forwardedIp(r *http.Request, trusted []*net.IPNet) string {
+	remoteIp, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIp = r.RemoteAddr
+	}
+	if !isTrusted(net.ParseIP(remoteIp), trusted) {
+		return ""
+	}
+
+	// every proxy appends the address it received the request from
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			return ""
+		}
+		if !isTrusted(ip, trusted) {
+			return ip.String()
+		}
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
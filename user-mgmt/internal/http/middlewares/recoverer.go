@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Recoverer is a middleware that recovers from panics like middleware.Recoverer,
+// logging them and answering 500. Unlike it, it lets http.ErrAbortHandler through
+// to the server, which then aborts the response: handlers that already sent their
+// status use it so clients see that the response was cut short.
+func Recoverer(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+
+			if logEntry := middleware.GetLogEntry(r); logEntry != nil {
+				logEntry.Panic(rvr, debug.Stack())
+			} else {
+				middleware.PrintPrettyStack(rvr)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -2,32 +2,57 @@ package middlewares
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/middleware"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
 	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
 	"go.uber.org/zap"
 )
 
+// redactedSegment replaces the part of logged paths that is redacted.
+const redactedSegment = "REDACTED"
+
 // RequestsLogger is a middleware that logs the start and end of each request, along
 // with some useful data about what was requested, what the response status was,
-// and how long it took to return.
-func RequestsLogger(logger logger.Logger) func(http.Handler) http.Handler {
+// and how long it took to return. Errors written as problems are logged with their
+// cause, which clients do not see for internal errors. Paths under one of the
+// redacted prefixes carry credentials, only their prefix is logged.
+func RequestsLogger(logger logger.Logger, redacted ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r, failure := problem.RecordErrors(r)
 			t1 := time.Now()
 			defer func() {
-				logger.ZapInfo("Served",
+				path := redactPath(r.URL.Path, redacted)
+				fields := []zap.Field{
 					zap.String("proto", r.Proto),
-					zap.String("path", r.URL.Path),
+					zap.String("path", path),
 					zap.Duration("lat", time.Since(t1)),
 					zap.Int("status", ww.Status()),
 					zap.Int("size", ww.BytesWritten()),
-					zap.String("reqId", middleware.GetReqID(r.Context())))
+					zap.String("reqId", middleware.GetReqID(r.Context())),
+				}
+				if *failure != nil {
+					// errors of unknown routes repeat their path
+					fields = append(fields, zap.String("error", strings.ReplaceAll((*failure).Error(), r.URL.Path, path)))
+				}
+				logger.ZapInfo("Served", fields...)
 			}()
 			next.ServeHTTP(ww, r)
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+// redactPath replaces what follows the first of prefixes that path starts with.
+func redactPath(path string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix+"/") {
+			return prefix + "/" + redactedSegment
+		}
+	}
+	return path
+}
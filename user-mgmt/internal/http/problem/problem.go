@@ -0,0 +1,80 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+)
+
+// ContentType is the media type of error responses, see RFC 7807.
+const ContentType = "application/problem+json"
+
+// Details is the body of error responses. Code is stable and identifies the
+// error, detail is a human readable explanation.
+type Details struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	RequestId string                 `json:"request_id,omitempty"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// Write writes err as a problem details response. Domain errors are reported
+// with their code and message, validation errors with the invalid fields, and any
+// other error as an internal error whose cause is only logged.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	domainErr := apperrors.As(err)
+	RecordError(r.Context(), err)
+	write(w, r, apperrors.HTTPStatus(domainErr), domainErr)
+}
+
+func write(w http.ResponseWriter, r *http.Request, status int, domainErr *apperrors.Error) {
+	problem := Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    domainErr.Message,
+		Instance:  r.URL.Path,
+		Code:      domainErr.Code,
+		RequestId: middleware.GetReqID(r.Context()),
+		Errors:    domainErr.Fields,
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// NotFound writes the problem of a request to an unknown route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, apperrors.NotFound(apperrors.CodeRouteNotFound, "no route for %s", r.URL.Path))
+}
+
+// MethodNotAllowed writes the problem of a request with a method the route does not support.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	err := apperrors.Invalid(apperrors.CodeMethodNotAllowed, "method %s is not allowed for %s", r.Method, r.URL.Path)
+	write(w, r, http.StatusMethodNotAllowed, err)
+}
+
+type errorRecorderKey struct{}
+
+// RecordErrors returns a copy of r whose context keeps the last error written with
+// Write, so that causes hidden from clients can be logged.
+func RecordErrors(r *http.Request) (*http.Request, *error) {
+	var recorded error
+	return r.WithContext(context.WithValue(r.Context(), errorRecorderKey{}, &recorded)), &recorded
+}
+
+// RecordError keeps err as the last error of the request, for errors that cannot
+// be written as a problem once the response started.
+func RecordError(ctx context.Context, err error) {
+	if recorded, ok := ctx.Value(errorRecorderKey{}).(*error); ok {
+		*recorded = err
+	}
+}
@@ -16,9 +16,17 @@ import (
 	"github.com/go-chi/render"
 	_ "github.com/pedromspeixoto/users-api/docs"
 	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/data/models/idempotency"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/audit"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/health"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/jobs"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/search"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/shares"
 	"github.com/pedromspeixoto/users-api/internal/http/handlers/users"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers/webhooks"
 	"github.com/pedromspeixoto/users-api/internal/http/middlewares"
+	"github.com/pedromspeixoto/users-api/internal/http/problem"
+	pkgaudit "github.com/pedromspeixoto/users-api/internal/pkg/audit"
 	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/fx"
@@ -30,16 +38,33 @@ func InvokeServer() fx.Option {
 
 type serverDependencies struct {
 	fx.In
+	RouterDependencies
 
-	LifeCycle            fx.Lifecycle
-	Config               *config.Config
-	Logger               *logger.LoggingClient
-	HealthServiceHandler health.HealthServiceHandler
-	MetricServiceHandler metrics.MetricServiceHandler
-	UserServiceHandler   users.UserServiceHandler
+	LifeCycle fx.Lifecycle
 }
 
-func NewHTTPServer(lc fx.Lifecycle, deps serverDependencies) *http.Server {
+// RouterDependencies are the handlers and middleware dependencies of the routes.
+type RouterDependencies struct {
+	fx.In
+
+	Config                   *config.Config
+	Logger                   *logger.LoggingClient
+	IdempotencyKeyRepository idempotency.IdempotencyKeyRepository
+	AuditServiceHandler      audit.AuditServiceHandler
+	HealthServiceHandler     health.HealthServiceHandler
+	JobServiceHandler        jobs.JobServiceHandler
+	MetricServiceHandler     metrics.MetricServiceHandler
+	SearchServiceHandler     search.SearchServiceHandler
+	ShareServiceHandler      shares.ShareServiceHandler
+	UserServiceHandler       users.UserServiceHandler
+	WebhookServiceHandler    webhooks.WebhookServiceHandler
+}
+
+func NewHTTPServer(lc fx.Lifecycle, deps serverDependencies) (*http.Server, error) {
+	router, err := NewRouter(deps.RouterDependencies)
+	if err != nil {
+		return nil, err
+	}
 
 	// http server definition
 	server := &http.Server{
@@ -54,7 +79,7 @@ func NewHTTPServer(lc fx.Lifecycle, deps serverDependencies) *http.Server {
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
 	// set routes
-	registerRoutes(server, deps)
+	server.Handler = router
 
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -68,7 +93,8 @@ func NewHTTPServer(lc fx.Lifecycle, deps serverDependencies) *http.Server {
 				<-signals
 
 				// shutdown signal with grace period of 30 seconds
-				shutdownCtx, _ := context.WithTimeout(serverCtx, 30*time.Second)
+				shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
+				defer cancel()
 				go func() {
 					<-shutdownCtx.Done()
 					if shutdownCtx.Err() == context.DeadlineExceeded {
@@ -88,38 +114,72 @@ func NewHTTPServer(lc fx.Lifecycle, deps serverDependencies) *http.Server {
 		},
 	})
 
-	return server
+	return server, nil
 }
 
-func registerRoutes(server *http.Server, deps serverDependencies) {
+const (
+	// requestTimeout bounds the requests of every route but archives.
+	requestTimeout = 60 * time.Second
+	// sharedPath is where share links are served, their token is left out of the logs.
+	sharedPath = "/v1/shared"
+)
+
+// NewRouter returns the router serving the routes of the API with their middlewares.
+func NewRouter(deps RouterDependencies) (chi.Router, error) {
+	realIp, err := middlewares.RealIP(deps.Config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	r := chi.NewRouter()
+	// set before mounting, mounted routers inherit them
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
 
 	r.Use(middleware.RequestID)
-	r.Use(middlewares.RequestsLogger(deps.Logger.GetLogger()))
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(realIp)
+	r.Use(middlewares.AuditContext)
+	r.Use(middlewares.RequestsLogger(deps.Logger.GetLogger(), sharedPath))
+	r.Use(middlewares.Recoverer)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
+	r.Use(middlewares.Idempotency(deps.IdempotencyKeyRepository, deps.Config.IdempotencyKeyTTL, deps.Logger.GetLogger()))
 
 	// cors support
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "sentry-trace", "baggage"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "sentry-trace", "baggage", pkgaudit.ActorHeader, middlewares.IdempotencyKeyHeader},
+		ExposedHeaders: []string{"Location", middlewares.IdempotentReplayedHeader},
 	}))
 
-	// swagger
-	r.Mount("/swagger", httpSwagger.WrapHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
 
-	// health
-	r.Mount("/health", deps.HealthServiceHandler.Routes())
+		// swagger
+		r.Mount("/swagger", httpSwagger.WrapHandler)
 
-	// prometheus metrics
-	r.Mount("/metrics", deps.MetricServiceHandler.Routes())
+		// health
+		r.Mount("/health", deps.HealthServiceHandler.Routes())
 
-	// routes
-	r.Group(func(r chi.Router) {
-		r.Mount("/v1/users", deps.UserServiceHandler.Routes())
+		// prometheus metrics
+		r.Mount("/metrics", deps.MetricServiceHandler.Routes())
+
+		// routes
+		r.Group(func(r chi.Router) {
+			r.Mount("/v1/users", deps.UserServiceHandler.Routes())
+			r.Mount("/v1/audit", deps.AuditServiceHandler.Routes())
+			r.Mount("/v1/webhooks", deps.WebhookServiceHandler.Routes())
+			r.Mount("/v1/jobs", deps.JobServiceHandler.Routes())
+			r.Mount("/v1/files:search", deps.SearchServiceHandler.Routes())
+		})
+
+		// share links are public, the signed token grants access
+		r.Mount(sharedPath, deps.ShareServiceHandler.Routes())
 	})
 
-	server.Handler = r
+	// archives are streamed for longer than other requests take, under their own deadline
+	r.With(middleware.Timeout(deps.Config.FileArchiveTimeout)).
+		Get("/v1/users/{userId}/files:archive", deps.UserServiceHandler.ArchiveUserFiles)
+
+	return r, nil
 }
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+)
+
+const (
+	// ActorHeader is the request header identifying who performed the request.
+	ActorHeader = "X-Actor-Id"
+	// AnonymousActor is used when a request does not identify its actor.
+	AnonymousActor = "anonymous"
+)
+
+type contextKey struct{}
+
+// Metadata describes who performed a request and from where.
+type Metadata struct {
+	Actor     string
+	RequestId string
+	ClientIp  string
+}
+
+// WithMetadata returns a copy of ctx carrying the given request metadata.
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, metadata)
+}
+
+// MetadataFromContext returns the request metadata stored in ctx, if any.
+func MetadataFromContext(ctx context.Context) Metadata {
+	metadata, ok := ctx.Value(contextKey{}).(Metadata)
+	if !ok || metadata.Actor == "" {
+		metadata.Actor = AnonymousActor
+	}
+	return metadata
+}
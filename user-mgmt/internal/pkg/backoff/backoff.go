@@ -0,0 +1,20 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Exponential returns base * 2^(attempt-1), capped at max, plus up to 20% jitter
+// so that clients retrying at the same time spread out. attempt starts at 1.
+func Exponential(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := time.Duration(math.Min(
+		float64(base)*math.Pow(2, float64(attempt-1)),
+		float64(max),
+	))
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
@@ -0,0 +1,138 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// cooledDown lets the cooldown of an open breaker pass.
+func cooledDown(b *CircuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = time.Now().Add(-b.cooldown)
+}
+
+// open makes threshold failed calls through b.
+func open(t *testing.T, b *CircuitBreaker) {
+	t.Helper()
+	for i := 0; i < b.threshold; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expected call %d to be allowed, got %v", i+1, err)
+		}
+		b.Failure()
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("expected the breaker to be open, got %s", b.State())
+	}
+}
+
+func TestBreakerOpensAfterThresholdConsecutiveFailures(t *testing.T) {
+	b := New(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expected call %d to be allowed, got %v", i+1, err)
+		}
+		b.Failure()
+		if b.State() != StateClosed {
+			t.Fatalf("expected the breaker to stay closed after %d failures, got %s", i+1, b.State())
+		}
+	}
+
+	// a success resets the count of consecutive failures
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected the call to be allowed, got %v", err)
+	}
+	b.Success()
+	for i := 0; i < 2; i++ {
+		b.Allow()
+		b.Failure()
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected the breaker to stay closed after a success, got %s", b.State())
+	}
+
+	b.Allow()
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("expected the breaker to open, got %s", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("expected %v while open, got %v", ErrOpen, err)
+	}
+}
+
+func TestBreakerLetsASingleTrialThroughOnceCooledDown(t *testing.T) {
+	b := New(1, time.Minute)
+	open(t, b)
+	cooledDown(b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected the trial call to be allowed, got %v", err)
+	}
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected the breaker to be half-open, got %s", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("expected %v during the trial, got %v", ErrOpen, err)
+	}
+}
+
+func TestBreakerClosesWhenTheTrialSucceeds(t *testing.T) {
+	b := New(1, time.Minute)
+	open(t, b)
+	cooledDown(b)
+
+	b.Allow()
+	b.Success()
+	if b.State() != StateClosed {
+		t.Fatalf("expected the breaker to close, got %s", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("expected calls to be allowed, got %v", err)
+	}
+}
+
+func TestBreakerReopensWhenTheTrialFails(t *testing.T) {
+	b := New(3, time.Minute)
+	open(t, b)
+	cooledDown(b)
+
+	// a single failed trial is enough, whatever the threshold
+	b.Allow()
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("expected the breaker to open again, got %s", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("expected %v for a new cooldown, got %v", ErrOpen, err)
+	}
+}
+
+func TestBreakerReleaseLeavesTheStateUnchanged(t *testing.T) {
+	b := New(1, time.Minute)
+
+	// released calls are no failures
+	for i := 0; i < 3; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expected call %d to be allowed, got %v", i+1, err)
+		}
+		b.Release()
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected the breaker to stay closed, got %s", b.State())
+	}
+
+	// a released trial frees the way for the next one
+	open(t, b)
+	cooledDown(b)
+	b.Allow()
+	b.Release()
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected the breaker to stay half-open, got %s", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("expected a new trial to be allowed, got %v", err)
+	}
+}
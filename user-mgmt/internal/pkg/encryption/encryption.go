@@ -0,0 +1,144 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+const (
+	ProviderLocal = "local"
+)
+
+// dataKeySize is the size of the AES-256 keys content is encrypted with.
+const dataKeySize = 32
+
+var (
+	ErrUnknownKey = errors.New("unknown master key")
+	ErrDecryption = errors.New("content could not be decrypted")
+)
+
+func ProvideKeyProvider() fx.Option {
+	return fx.Provide(
+		NewKeyProvider,
+	)
+}
+
+// KeyProvider wraps data keys with master keys it never hands out, the way a KMS
+// does. Master keys are identified by an id stored next to every wrapped key, so
+// older keys keep unwrapping after a new one becomes active.
+type KeyProvider interface {
+	// ActiveKeyId returns the id of the master key new data keys are wrapped with.
+	ActiveKeyId() string
+	// Wrap encrypts dataKey with the active master key, returning its id.
+	Wrap(dataKey []byte) (keyId string, wrapped []byte, err error)
+	// Unwrap decrypts a data key wrapped with the master key keyId.
+	Unwrap(keyId string, wrapped []byte) ([]byte, error)
+}
+
+type keyProviderDeps struct {
+	fx.In
+
+	Config *config.Config
+	Logger *logger.LoggingClient
+}
+
+func NewKeyProvider(deps keyProviderDeps) (KeyProvider, error) {
+	switch deps.Config.EncryptionKeyProvider {
+	case ProviderLocal:
+		return NewLocalKeyProvider(deps.Config.EncryptionKeyFile, deps.Config.EncryptionKeyId, deps.Config.EncryptionGenerateKey, deps.Logger.GetLogger())
+	}
+	return nil, fmt.Errorf("unknown key provider %s", deps.Config.EncryptionKeyProvider)
+}
+
+// Envelope is content encrypted with its own data key, and that data key wrapped
+// by the master key KeyId.
+type Envelope struct {
+	KeyId      string
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// Seal encrypts plaintext with a new data key wrapped by the active master key.
+func Seal(provider KeyProvider, plaintext []byte) (*Envelope, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	ciphertext, err := sealGCM(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	keyId, wrapped, err := provider.Wrap(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		KeyId:      keyId,
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Open decrypts the content of envelope.
+func Open(provider KeyProvider, envelope *Envelope) ([]byte, error) {
+	dataKey, err := provider.Unwrap(envelope.KeyId, envelope.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return openGCM(dataKey, envelope.Ciphertext)
+}
+
+// Rewrap wraps the data key of an envelope with the active master key, leaving
+// the content encrypted with it untouched.
+func Rewrap(provider KeyProvider, keyId string, wrapped []byte) (string, []byte, error) {
+	dataKey, err := provider.Unwrap(keyId, wrapped)
+	if err != nil {
+		return "", nil, err
+	}
+	return provider.Wrap(dataKey)
+}
+
+// sealGCM encrypts plaintext with AES-GCM, prefixing the result with its nonce.
+func sealGCM(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func openGCM(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecryption
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
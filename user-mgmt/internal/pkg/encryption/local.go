@@ -0,0 +1,113 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+)
+
+// LocalKeyProvider keeps master keys in a keyfile with one "<id> <base64 key>" line
+// per key, blank lines and lines starting with # being ignored. Keys are rotated by
+// appending a new line: the last key is the active one unless an id is configured.
+type LocalKeyProvider struct {
+	keys   map[string][]byte
+	active string
+}
+
+// NewLocalKeyProvider reads the keyfile at path. A missing keyfile is an error unless
+// generate is set, for development, as instances sharing content must share their
+// master keys.
+func NewLocalKeyProvider(path, activeKeyId string, generate bool, log logger.Logger) (*LocalKeyProvider, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !generate {
+			return nil, fmt.Errorf("keyfile %s not found, mount the master keys or set ENCRYPTION_GENERATE_KEY in development", path)
+		}
+		log.Warningf("keyfile %s not found, generating a new master key. keep it safe, encrypted content is lost without it", path)
+		content, err = generateKeyfile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read keyfile %s: %v", path, err)
+	}
+
+	provider := &LocalKeyProvider{
+		keys: map[string][]byte{},
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("keyfile %s line %d: expected a key id and a key", path, line)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("keyfile %s line %d: key must be %d base64 encoded bytes", path, line, dataKeySize)
+		}
+		provider.keys[fields[0]] = key
+		provider.active = fields[0]
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if activeKeyId != "" {
+		provider.active = activeKeyId
+	}
+	if _, ok := provider.keys[provider.active]; !ok {
+		return nil, fmt.Errorf("%w %q in keyfile %s", ErrUnknownKey, provider.active, path)
+	}
+	return provider, nil
+}
+
+func (p *LocalKeyProvider) ActiveKeyId() string {
+	return p.active
+}
+
+func (p *LocalKeyProvider) Wrap(dataKey []byte) (string, []byte, error) {
+	wrapped, err := sealGCM(p.keys[p.active], dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.active, wrapped, nil
+}
+
+func (p *LocalKeyProvider) Unwrap(keyId string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyId)
+	}
+	return openGCM(key, wrapped)
+}
+
+// generateKeyfile writes a keyfile holding a single new master key, readable by
+// its owner only.
+func generateKeyfile(path string) ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	content := []byte(fmt.Sprintf("%s %s\n", time.Now().UTC().Format("20060102150405"), base64.StdEncoding.EncodeToString(key)))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
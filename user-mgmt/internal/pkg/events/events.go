@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+const (
+	TypeUserCreated = "user.created"
+	TypeUserDeleted = "user.deleted"
+	TypeFileCreated = "file.created"
+	TypeFileDeleted = "file.deleted"
+	TypeFileUpdated = "file.updated"
+)
+
+const (
+	SinkStdout  = "stdout"
+	SinkWebhook = "webhook"
+	SinkNats    = "nats"
+	SinkKafka   = "kafka"
+)
+
+func ProvideSinks() fx.Option {
+	return fx.Provide(
+		NewSinks,
+	)
+}
+
+// Event is the envelope published to sinks.
+type Event struct {
+	Id            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Sink publishes events to a downstream system. Publish must only return nil
+// once the event has been accepted, events may be delivered more than once.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Publish delivers the event.
+	Publish(ctx context.Context, event *Event) error
+	// Close releases any resources held by the sink.
+	Close() error
+}
+
+// Sinks are all the sinks events are relayed to.
+type Sinks []Sink
+
+type sinksDeps struct {
+	fx.In
+
+	LifeCycle fx.Lifecycle
+	Config    *config.Config
+	Logger    *logger.LoggingClient
+}
+
+func NewSinks(deps sinksDeps) (Sinks, error) {
+	var sinks Sinks
+	for _, name := range deps.Config.OutboxSinks {
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case SinkStdout:
+			sinks = append(sinks, NewStdoutSink())
+		case SinkWebhook:
+			sink, err := NewWebhookSink(deps.Config.OutboxWebhookUrl, deps.Config.OutboxWebhookTimeout)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case SinkNats:
+			sinks = append(sinks, NewNatsSink(deps.Config.NatsUrl, deps.Config.NatsSubjectPrefix))
+		case SinkKafka:
+			sinks = append(sinks, NewKafkaSink(deps.Config.KafkaBrokers, deps.Config.KafkaTopic))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+
+	deps.LifeCycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			for _, sink := range sinks {
+				if err := sink.Close(); err != nil {
+					deps.Logger.GetLogger().Errorf("failed to close %s sink: %v", sink.Name(), err)
+				}
+			}
+			return nil
+		},
+	})
+
+	return sinks, nil
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink writes each event to a topic, keyed by aggregate id so that events
+// of the same user or file keep their order.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (s *KafkaSink) Name() string {
+	return SinkKafka
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateId),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.Id)},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write event %s to kafka: %v", event.Id, err)
+	}
+	return nil
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
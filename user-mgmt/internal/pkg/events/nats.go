@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NatsSink publishes each event to the "<prefix>.<event type>" subject.
+type NatsSink struct {
+	url    string
+	prefix string
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+func NewNatsSink(url, prefix string) *NatsSink {
+	return &NatsSink{
+		url:    url,
+		prefix: prefix,
+	}
+}
+
+func (s *NatsSink) Name() string {
+	return SinkNats
+}
+
+func (s *NatsSink) Publish(ctx context.Context, event *Event) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(fmt.Sprintf("%s.%s", s.prefix, event.Type))
+	msg.Header.Set(nats.MsgIdHdr, event.Id)
+	msg.Data = body
+	if err = conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("failed to publish event %s to nats: %v", event.Id, err)
+	}
+
+	// wait for the server to acknowledge everything published so far
+	if err = conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush event %s to nats: %v", event.Id, err)
+	}
+	return nil
+}
+
+// connection lazily connects so that an unavailable server only delays delivery.
+func (s *NatsSink) connection() (*nats.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil && !s.conn.IsClosed() {
+		return s.conn, nil
+	}
+
+	conn, err := nats.Connect(s.url, nats.Name("users-api-outbox"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats at %s: %v", s.url, err)
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+func (s *NatsSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return s.conn.Drain()
+	}
+	return nil
+}
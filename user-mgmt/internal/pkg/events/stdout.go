@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdoutSink writes each event as a json line to stdout.
+type StdoutSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{
+		w: os.Stdout,
+	}
+}
+
+func (s *StdoutSink) Name() string {
+	return SinkStdout
+}
+
+func (s *StdoutSink) Publish(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.w).Encode(event)
+}
+
+func (s *StdoutSink) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookSink posts each event as json to a fixed url.
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookSink(webhookUrl string, timeout time.Duration) (*WebhookSink, error) {
+	if _, err := url.ParseRequestURI(webhookUrl); err != nil {
+		return nil, fmt.Errorf("invalid outbox webhook url %q: %v", webhookUrl, err)
+	}
+	return &WebhookSink{
+		url:        webhookUrl,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *WebhookSink) Name() string {
+	return SinkWebhook
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.Id)
+	req.Header.Set("X-Event-Type", event.Type)
+
+	response, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event %s to %s: %v", event.Id, s.url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("failed to post event %s to %s: unexpected status %d", event.Id, s.url, response.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	s.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -1,17 +1,46 @@
 package files
 
 import (
-	"github.com/pedromspeixoto/users-api/internal/config"
-	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
-	"go.uber.org/fx"
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"time"
 
-	"github.com/pkg/errors"
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/backoff"
+	"github.com/pedromspeixoto/users-api/internal/pkg/circuitbreaker"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+var (
+	// ErrUpstreamUnavailable is returned without calling the file server while it
+	// is considered unhealthy.
+	ErrUpstreamUnavailable = errors.New("file server is unavailable")
+	// ErrUpstreamTimeout is returned when the file server did not answer in time.
+	ErrUpstreamTimeout = errors.New("file server timed out")
+	// ErrUpstreamFailure is returned when the file server could not be reached or
+	// answered with an unexpected response.
+	ErrUpstreamFailure = errors.New("file server request failed")
 )
 
+// UpstreamStatusError is returned when the file server answers with a non 200 status.
+type UpstreamStatusError struct {
+	StatusCode int
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return fmt.Sprintf("%v: unexpected status %d", ErrUpstreamFailure, e.StatusCode)
+}
+
+func (e *UpstreamStatusError) Unwrap() error {
+	return ErrUpstreamFailure
+}
+
 func ProvideFileServingClient() fx.Option {
 	return fx.Provide(NewFileServingClient)
 }
@@ -29,6 +58,11 @@ type fileServingDeps struct {
 
 type FileServingClient struct {
 	fileServingUrl string
+	timeout        time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	maxSize        int64
+	breaker        *circuitbreaker.CircuitBreaker
 	httpClient
 	logger.Logger
 }
@@ -52,8 +86,16 @@ func NewFileServingClient(deps fileServingDeps, opts ...clientOption) (*FileServ
 
 	client := &FileServingClient{
 		fileServingUrl: deps.Config.FileServingUrl,
-		httpClient:     http.DefaultClient,
-		Logger:         deps.Logger.GetLogger(),
+		timeout:        deps.Config.FileServingTimeout,
+		maxRetries:     deps.Config.FileServingMaxRetries,
+		retryBackoff:   deps.Config.FileServingRetryBackoff,
+		maxSize:        deps.Config.FileMaxSize,
+		breaker: circuitbreaker.New(
+			deps.Config.FileServingBreakerThreshold,
+			deps.Config.FileServingBreakerCooldown,
+		),
+		httpClient: &http.Client{},
+		Logger:     deps.Logger.GetLogger(),
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -62,31 +104,89 @@ func NewFileServingClient(deps fileServingDeps, opts ...clientOption) (*FileServ
 	return client, nil
 }
 
-type RequestOptions struct {
-	Logger log.Logger
+// GetRandomFile fetches a file from the file-serving service, returning its content
+// and declared content type. Transient failures are retried with jittered backoff.
+// Errors wrap ErrUpstreamUnavailable, ErrUpstreamTimeout or ErrUpstreamFailure, or
+// ErrFileTooLarge when the file exceeds the configured maximum size.
+func (c *FileServingClient) GetRandomFile(ctx context.Context) ([]byte, string, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var fileContent []byte
+		var fileType string
+		fileContent, fileType, err = c.fetch(ctx, c.fileServingUrl)
+		if err == nil {
+			return fileContent, fileType, nil
+		}
+		if attempt > c.maxRetries || !retryable(err) || ctx.Err() != nil {
+			return nil, "", err
+		}
+
+		delay := backoff.Exponential(attempt, c.retryBackoff, 10*c.retryBackoff)
+		c.Logger.Warningf("failed to fetch file from %s (attempt %d), retrying in %s: %v", c.fileServingUrl, attempt, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, "", fmt.Errorf("%w: %v", ErrUpstreamTimeout, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
 }
 
-func (c *FileServingClient) GetRandomFile(opts ...RequestOptions) ([]byte, string, error) {
+func (c *FileServingClient) fetch(ctx context.Context, fileURL string) ([]byte, string, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+
+	fileContent, fileType, err := c.do(ctx, fileURL)
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		// the caller went away, which is no failure of the file server
+		c.breaker.Release()
+	case err != nil && retryable(err):
+		c.breaker.Failure()
+	default:
+		c.breaker.Success()
+	}
+	return fileContent, fileType, err
+}
+
+func (c *FileServingClient) do(ctx context.Context, fileURL string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
 
 	// fetch the file from the file-serving service
-	fileURL := c.fileServingUrl
-	response, err := http.Get(fileURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUpstreamFailure, err)
+	}
+
+	response, err := c.httpClient.Do(req)
 	if err != nil {
 		c.Logger.Errorf("failed to fetch file from %s. Error: %v", fileURL, err)
-		return nil, "", err
+		return nil, "", upstreamError(err)
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		c.Logger.Errorf("failed to fetch file from %s. Error: %v", fileURL, err)
-		return nil, "", err
+		c.Logger.Errorf("failed to fetch file from %s. Status: %d", fileURL, response.StatusCode)
+		return nil, "", &UpstreamStatusError{StatusCode: response.StatusCode}
+	}
+
+	if c.maxSize > 0 && response.ContentLength > c.maxSize {
+		return nil, "", fmt.Errorf("%w: %d bytes", ErrFileTooLarge, response.ContentLength)
 	}
 
-	// read the file content from the response body
-	fileContent, err := io.ReadAll(response.Body)
+	// read the file content from the response body, never more than allowed
+	body := io.Reader(response.Body)
+	if c.maxSize > 0 {
+		body = io.LimitReader(response.Body, c.maxSize+1)
+	}
+	fileContent, err := io.ReadAll(body)
 	if err != nil {
 		c.Logger.Errorf("failed to read file from response body. Error: %v", err)
-		return nil, "", err
+		return nil, "", upstreamError(err)
+	}
+	if c.maxSize > 0 && int64(len(fileContent)) > c.maxSize {
+		return nil, "", fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, c.maxSize)
 	}
 
 	// get file type from response header
@@ -94,3 +194,21 @@ func (c *FileServingClient) GetRandomFile(opts ...RequestOptions) ([]byte, strin
 
 	return fileContent, fileType, nil
 }
+
+func upstreamError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", ErrUpstreamFailure, err)
+}
+
+// retryable reports whether err is a transient failure worth retrying: timeouts,
+// connection errors, 429 and 5xx responses.
+func retryable(err error) bool {
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return errors.Is(err, ErrUpstreamTimeout) || errors.Is(err, ErrUpstreamFailure)
+}
@@ -0,0 +1,113 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/circuitbreaker"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+)
+
+func newTestClient(t *testing.T, server *httptest.Server, threshold int) *FileServingClient {
+	t.Helper()
+	client, err := NewFileServingClient(fileServingDeps{
+		Config: &config.Config{
+			FileServingUrl:              server.URL,
+			FileServingTimeout:          time.Second,
+			FileServingMaxRetries:       2,
+			FileServingRetryBackoff:     time.Millisecond,
+			FileServingBreakerThreshold: threshold,
+			FileServingBreakerCooldown:  time.Minute,
+		},
+		Logger: &logger.LoggingClient{},
+	})
+	if err != nil {
+		t.Fatalf("could not create the client: %v", err)
+	}
+	return client
+}
+
+func TestClientOpensTheBreakerOnServerFailures(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server, 3)
+	if _, _, err := client.GetRandomFile(context.Background()); err == nil {
+		t.Fatalf("expected the failing server to fail the call")
+	}
+	if client.breaker.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected the breaker to open, got %s", client.breaker.State())
+	}
+
+	sent := atomic.LoadInt32(&requests)
+	if _, _, err := client.GetRandomFile(context.Background()); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected %v while the breaker is open, got %v", ErrUpstreamUnavailable, err)
+	}
+	if atomic.LoadInt32(&requests) != sent {
+		t.Errorf("expected no request while the breaker is open")
+	}
+}
+
+func TestClientDoesNotCountCallerCancellationsAsFailures(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server, 1)
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+		if _, _, err := client.GetRandomFile(ctx); err == nil {
+			t.Fatalf("expected the cancelled call to fail")
+		}
+		cancel()
+	}
+
+	if client.breaker.State() != circuitbreaker.StateClosed {
+		t.Errorf("expected the breaker to stay closed, got %s", client.breaker.State())
+	}
+	// cancelled calls are not retried
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expected a single request per call, got %d", n)
+	}
+}
+
+func TestClientCountsTimeoutsAsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server, 1)
+	client.timeout = 20 * time.Millisecond
+	client.maxRetries = 0
+
+	if _, _, err := client.GetRandomFile(context.Background()); !errors.Is(err, ErrUpstreamTimeout) {
+		t.Fatalf("expected %v, got %v", ErrUpstreamTimeout, err)
+	}
+	if client.breaker.State() != circuitbreaker.StateOpen {
+		t.Errorf("expected the breaker to open, got %s", client.breaker.State())
+	}
+}
@@ -0,0 +1,99 @@
+package files
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFilenameLength is the maximum length of a filename in bytes.
+const maxFilenameLength = 255
+
+// SanitizeFilename reduces name to a safe base name: directories, control and
+// separator characters are dropped and the result is truncated to 255 bytes on a
+// character boundary. It returns an empty string when nothing usable is left.
+func SanitizeFilename(name string) string {
+	// clients on any platform may send a full path
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) || unicode.Is(unicode.Zl, r) || unicode.Is(unicode.Zp, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// FilenameFromURL returns the sanitized last path segment of a url, if any.
+func FilenameFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Path == "" || strings.HasSuffix(parsed.Path, "/") {
+		return ""
+	}
+	return SanitizeFilename(path.Base(parsed.Path))
+}
+
+// ContentDisposition returns an attachment Content-Disposition header for a file
+// named name as described in RFC 6266: an ASCII only filename for older clients
+// and the exact name, percent encoded as UTF-8, in filename*.
+func ContentDisposition(name string) string {
+	name = SanitizeFilename(name)
+
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range name {
+		switch {
+		case r > unicode.MaxASCII:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	header := fmt.Sprintf("attachment; filename=\"%s\"", fallback.String())
+	if !ascii || strings.ContainsAny(name, `"\%`) {
+		header += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+	return header
+}
+
+// encodeRFC5987 percent encodes every byte of value that is not an attr-char.
+func encodeRFC5987(value string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAttrChar(c) {
+			encoded.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", c)
+	}
+	return encoded.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
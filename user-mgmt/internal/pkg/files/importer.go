@@ -0,0 +1,228 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+var (
+	// ErrURLNotAllowed is returned when the url scheme or host is not in the allow-list.
+	ErrURLNotAllowed = errors.New("url is not allowed")
+	// ErrAddressBlocked is returned when the url resolves to a private, loopback,
+	// link-local or otherwise non public address.
+	ErrAddressBlocked = errors.New("url resolves to a blocked address")
+	// ErrFileTooLarge is returned when the file exceeds the maximum allowed size.
+	ErrFileTooLarge = errors.New("file exceeds the maximum allowed size")
+	// ErrTooManyRedirects is returned when the url redirects too many times.
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// blockedNetworks are the ranges not covered by the net.IP helpers that must never
+// be reached from an import.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved, includes broadcast
+	"64:ff9b::/96",    // NAT64
+	"2001:db8::/32",   // documentation
+)
+
+func ProvideURLImporter() fx.Option {
+	return fx.Provide(NewURLImporter)
+}
+
+type urlImporterDeps struct {
+	fx.In
+
+	Config *config.Config
+	Logger *logger.LoggingClient
+}
+
+// URLImporter downloads files from caller supplied urls. Urls are restricted by a
+// scheme and host allow-list, an empty host allow-list denies every url. Every
+// address is checked after DNS resolution so that only public addresses are
+// dialed, and size and redirects are bounded.
+type URLImporter struct {
+	allowedSchemes []string
+	allowedHosts   []string
+	maxSize        int64
+	maxRedirects   int
+	httpClient     *http.Client
+	logger.Logger
+}
+
+func NewURLImporter(deps urlImporterDeps) *URLImporter {
+	importer := &URLImporter{
+		allowedSchemes: normalize(deps.Config.FileImportAllowedSchemes),
+		allowedHosts:   normalize(deps.Config.FileImportAllowedHosts),
+		maxSize:        deps.Config.FileImportMaxSize,
+		maxRedirects:   deps.Config.FileImportMaxRedirects,
+		Logger:         deps.Logger.GetLogger(),
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return CheckAddress(address)
+		},
+	}
+	importer.httpClient = &http.Client{
+		Timeout: deps.Config.FileImportTimeout,
+		Transport: &http.Transport{
+			// never go through a proxy, it would dial on our behalf without checks
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > importer.maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return importer.checkURL(req.URL)
+		},
+	}
+
+	return importer
+}
+
+// Import downloads the file at rawURL, returning its content and declared content type.
+func (i *URLImporter) Import(ctx context.Context, rawURL string) ([]byte, string, error) {
+	fileURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrURLNotAllowed, err)
+	}
+	if err = i.checkURL(fileURL); err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrURLNotAllowed, err)
+	}
+
+	response, err := i.httpClient.Do(req)
+	if err != nil {
+		i.Logger.Warningf("failed to import file from %s. Error: %v", fileURL.Redacted(), err)
+		if errors.Is(err, ErrURLNotAllowed) || errors.Is(err, ErrAddressBlocked) || errors.Is(err, ErrTooManyRedirects) {
+			return nil, "", err
+		}
+		return nil, "", upstreamError(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, "", &UpstreamStatusError{StatusCode: response.StatusCode}
+	}
+	if response.ContentLength > i.maxSize {
+		return nil, "", fmt.Errorf("%w: %d bytes", ErrFileTooLarge, response.ContentLength)
+	}
+
+	// read one byte past the limit to detect larger bodies without a content length
+	fileContent, err := io.ReadAll(io.LimitReader(response.Body, i.maxSize+1))
+	if err != nil {
+		return nil, "", upstreamError(err)
+	}
+	if int64(len(fileContent)) > i.maxSize {
+		return nil, "", fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, i.maxSize)
+	}
+
+	return fileContent, response.Header.Get("Content-Type"), nil
+}
+
+func (i *URLImporter) checkURL(u *url.URL) error {
+	if !contains(i.allowedSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q", ErrURLNotAllowed, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in url", ErrURLNotAllowed)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrURLNotAllowed)
+	}
+	for _, allowed := range i.allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: host %q", ErrURLNotAllowed, host)
+}
+
+// CheckAddress rejects resolved addresses that are not public unicast addresses. It
+// is meant as the Control of dialers that reach caller supplied urls.
+func CheckAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAddressBlocked, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %q is not an ip address", ErrAddressBlocked, host)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", ErrAddressBlocked, ip)
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrAddressBlocked, ip)
+		}
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func normalize(values []string) []string {
+	var normalized []string
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			normalized = append(normalized, v)
+		}
+	}
+	return normalized
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package files
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/unidoc/unipdf/v3/extractor"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+// maxPDFInfoLength is the number of runes kept of the title and author of a PDF,
+// the size of the columns they are stored in.
+const maxPDFInfoLength = 1024
+
+// PDFMetadata is what is known about a PDF document from its structure and info dictionary.
+type PDFMetadata struct {
+	PageCount int
+	Title     string
+	Author    string
+	CreatedAt *time.Time
+	Encrypted bool
+	Version   string
+}
+
+// ExtractPDFMetadata opens a PDF and reads its metadata. Documents encrypted with
+// an empty user password, only protected against editing, are decrypted to do so.
+func ExtractPDFMetadata(content []byte) (*PDFMetadata, error) {
+	reader, err := model.NewPdfReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &PDFMetadata{
+		Version: reader.PdfVersion().String(),
+	}
+
+	metadata.Encrypted, err = reader.IsEncrypted()
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Encrypted {
+		if _, err = reader.Decrypt([]byte("")); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata.PageCount, err = reader.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+
+	// the info dictionary is optional
+	info, err := reader.GetPdfInfo()
+	if err != nil {
+		return metadata, nil
+	}
+	if info.Title != nil {
+		metadata.Title = truncateRunes(info.Title.Decoded(), maxPDFInfoLength)
+	}
+	if info.Author != nil {
+		metadata.Author = truncateRunes(info.Author.Decoded(), maxPDFInfoLength)
+	}
+	if info.CreationDate != nil {
+		createdAt := info.CreationDate.ToGoTime()
+		metadata.CreatedAt = &createdAt
+	}
+
+	return metadata, nil
+}
+
+// truncateRunes returns the first max runes of s.
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// ExtractPDFText extracts the text of every page of a PDF, in reading order, and
+// stops once maxBytes of text were extracted.
+func ExtractPDFText(content []byte, maxBytes int) (string, error) {
+	reader, err := model.NewPdfReader(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	if encrypted, err := reader.IsEncrypted(); err == nil && encrypted {
+		if _, err = reader.Decrypt([]byte("")); err != nil {
+			return "", err
+		}
+	}
+
+	pages, err := reader.GetNumPages()
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for i := 1; i <= pages && text.Len() < maxBytes; i++ {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			return "", err
+		}
+		pageExtractor, err := extractor.New(page)
+		if err != nil {
+			return "", err
+		}
+		pageText, err := pageExtractor.ExtractText()
+		if err != nil {
+			return "", err
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+
+	extracted := text.String()
+	if len(extracted) > maxBytes {
+		extracted = strings.ToValidUTF8(extracted[:maxBytes], "")
+	}
+	return extracted, nil
+}
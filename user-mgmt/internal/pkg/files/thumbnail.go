@@ -0,0 +1,145 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/unidoc/unipdf/v3/model"
+	"github.com/unidoc/unipdf/v3/render"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const ThumbnailContentType = MimeTypePNG
+
+// ErrThumbnailUnsupported is returned for file types without previews.
+var ErrThumbnailUnsupported = errors.New("thumbnails are not supported for this file type")
+
+// Thumbnail renders a PNG preview of content that fits in a size x size box, keeping
+// the aspect ratio. Images are scaled down, PDFs get their first page rendered or,
+// when rendering fails, a card with their metadata.
+func Thumbnail(content []byte, fileType string, size int) ([]byte, error) {
+	var preview image.Image
+	var err error
+	switch MediaType(fileType) {
+	case MimeTypePNG, MimeTypeJPEG, MimeTypeGIF:
+		preview, err = imageThumbnail(content, size)
+	case MimeTypePDF:
+		preview, err = pdfThumbnail(content, size)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrThumbnailUnsupported, fileType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, preview); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func imageThumbnail(content []byte, size int) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if err = checkImageSize(config); err != nil {
+		return nil, err
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return scale(source, size), nil
+}
+
+func pdfThumbnail(content []byte, size int) (image.Image, error) {
+	reader, err := model.NewPdfReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := reader.GetPage(1)
+	if err == nil {
+		device := render.NewImageDevice()
+		device.OutputWidth = size
+		var rendered image.Image
+		if rendered, err = device.Render(page); err == nil {
+			return scale(rendered, size), nil
+		}
+	}
+
+	// rendering needs a licensed unipdf, fall back to what we can read
+	return pdfCard(reader, size), nil
+}
+
+// pdfCard draws a portrait card with the title and page count of a PDF.
+func pdfCard(reader *model.PdfReader, size int) image.Image {
+	lines := []string{"PDF"}
+	if info, err := reader.GetPdfInfo(); err == nil && info.Title != nil && info.Title.Decoded() != "" {
+		lines = append(lines, info.Title.Decoded())
+	}
+	if pages, err := reader.GetNumPages(); err == nil {
+		lines = append(lines, fmt.Sprintf("%d pages", pages))
+	}
+
+	// cards are drawn at a readable size and scaled to the requested one
+	width, height := 160, 208
+	card := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(card, card.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(card, image.Rect(0, 0, width, 28), image.NewUniform(color.RGBA{R: 0xd3, G: 0x2f, B: 0x2f, A: 0xff}), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	maxChars := (width - 16) / face.Advance
+	drawer := &font.Drawer{Dst: card, Face: face}
+	for i, line := range lines {
+		if len(line) > maxChars {
+			line = line[:maxChars-3] + "..."
+		}
+		drawer.Src = image.NewUniform(color.Black)
+		if i == 0 {
+			drawer.Src = image.NewUniform(color.White)
+		}
+		drawer.Dot = fixed.P(8, 19+i*24)
+		drawer.DrawString(line)
+	}
+
+	return scale(card, size)
+}
+
+// scale resizes source to fit in a size x size box, never enlarging it.
+func scale(source image.Image, size int) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return source
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), source, bounds, draw.Src, nil)
+	return thumbnail
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
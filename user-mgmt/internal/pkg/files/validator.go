@@ -1,21 +1,253 @@
 package files
 
 import (
+	"archive/zip"
 	"bytes"
-	"github.com/unidoc/unipdf/v3/model"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
 )
 
+const (
+	MimeTypePNG         = "image/png"
+	MimeTypeJPEG        = "image/jpeg"
+	MimeTypeGIF         = "image/gif"
+	MimeTypePDF         = "application/pdf"
+	MimeTypeZIP         = "application/zip"
+	MimeTypeOctetStream = "application/octet-stream"
+	MimeTypeDOCX        = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeTypeXLSX        = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MimeTypePPTX        = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+)
+
+const (
+	// maxImagePixels guards against images that are small on disk but huge once decoded.
+	maxImagePixels = 64 << 20
+	// maxZipUncompressedSize guards against archives that inflate to huge sizes.
+	maxZipUncompressedSize = 256 << 20
+	// maxContentTypesSize bounds how much of the [Content_Types].xml of documents
+	// stored as ZIP archives is read.
+	maxContentTypesSize = 1 << 20
+)
+
+// zipContainers maps the types of documents stored as ZIP archives, which are sniffed
+// as such, to the content type of their main part in [Content_Types].xml.
+var zipContainers = map[string]string{
+	MimeTypeDOCX: MimeTypeDOCX + ".main+xml",
+	MimeTypeXLSX: MimeTypeXLSX + ".main+xml",
+	MimeTypePPTX: MimeTypePPTX + ".main+xml",
+}
+
+// mimeTypeAliases maps non standard mime types seen in the wild to their standard name.
+var mimeTypeAliases = map[string]string{
+	"image/jpg":                    MimeTypeJPEG,
+	"image/pjpeg":                  MimeTypeJPEG,
+	"image/x-png":                  MimeTypePNG,
+	"application/x-pdf":            MimeTypePDF,
+	"application/x-zip":            MimeTypeZIP,
+	"application/x-zip-compressed": MimeTypeZIP,
+}
+
+var (
+	ErrContentTypeMismatch = errors.New("declared content type does not match the content")
+	ErrCorruptedContent    = errors.New("content is corrupted")
+)
+
+// ContentValidator checks that content is well formed for the type it is registered for.
+type ContentValidator func(content []byte) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]ContentValidator{
+		MimeTypePNG:  CheckPNGCorrupted,
+		MimeTypeJPEG: CheckJPEGCorrupted,
+		MimeTypeGIF:  CheckGIFCorrupted,
+		MimeTypePDF:  CheckPDFCorrupted,
+		MimeTypeZIP:  CheckZIPCorrupted,
+	}
+)
+
+// RegisterValidator registers the validator for a mime type, replacing any existing one.
+func RegisterValidator(mimeType string, validator ContentValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[mimeType] = validator
+}
+
+func validatorFor(mimeType string) (ContentValidator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	validator, ok := validators[mimeType]
+	return validator, ok
+}
+
+// SniffContentType detects the mime type of content, without parameters.
+func SniffContentType(content []byte) string {
+	return MediaType(http.DetectContentType(content))
+}
+
+// ValidateContent sniffs the type of content, rejects it when it contradicts the
+// declared content type and checks it with the validator registered for its type.
+// It returns the content type to store: the sniffed one when a validator vouched
+// for it, the declared one otherwise. Documents stored as ZIP archives, like docx,
+// are sniffed as such and keep their declared type once their parts are checked.
+func ValidateContent(declaredType string, content []byte) (string, error) {
+	declared := MediaType(declaredType)
+	sniffed := SniffContentType(content)
+
+	validator, sniffedKnown := validatorFor(sniffed)
+	_, declaredKnown := validatorFor(declared)
+
+	if mainPart, ok := zipContainers[declared]; ok && sniffed == MimeTypeZIP {
+		if err := checkZIPContainer(content, mainPart); err != nil {
+			return "", err
+		}
+		return declared, nil
+	}
+
+	// a generic or missing declaration never contradicts the content
+	generic := declared == "" || declared == MimeTypeOctetStream
+	if !generic && declared != sniffed && (sniffedKnown || declaredKnown) {
+		return "", fmt.Errorf("%w: declared %s, detected %s", ErrContentTypeMismatch, declared, sniffed)
+	}
+
+	if !sniffedKnown {
+		if generic {
+			return sniffed, nil
+		}
+		return declaredType, nil
+	}
+
+	if err := validator(content); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptedContent, err)
+	}
+	return sniffed, nil
+}
+
+// MediaType returns the standard mime type of a content type header, without parameters.
+func MediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		parsed = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, ok := mimeTypeAliases[parsed]; ok {
+		return alias
+	}
+	return parsed
+}
+
 func CheckPDFCorrupted(content []byte) error {
-	reader := bytes.NewReader(content)
-	pdfReader, err := model.NewPdfReader(reader)
+	_, err := ExtractPDFMetadata(content)
+	return err
+}
+
+func CheckPNGCorrupted(content []byte) error {
+	return checkImage(content, png.DecodeConfig, png.Decode)
+}
+
+func CheckJPEGCorrupted(content []byte) error {
+	return checkImage(content, jpeg.DecodeConfig, jpeg.Decode)
+}
+
+func CheckGIFCorrupted(content []byte) error {
+	config, err := gif.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	if err = checkImageSize(config); err != nil {
+		return err
+	}
+
+	// every frame has to decode, not only the first one
+	_, err = gif.DecodeAll(bytes.NewReader(content))
+	return err
+}
+
+func CheckZIPCorrupted(content []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
 	if err != nil {
 		return err
 	}
 
-	_, err = pdfReader.GetNumPages()
+	// reading every entry to the end verifies its checksum
+	var remaining int64 = maxZipUncompressedSize
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+		read, err := io.Copy(io.Discard, io.LimitReader(entry, remaining+1))
+		entry.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+		remaining -= read
+		if remaining < 0 {
+			return fmt.Errorf("archive inflates to more than %d bytes", maxZipUncompressedSize)
+		}
+	}
+	return nil
+}
+
+// checkZIPContainer checks a document stored as a ZIP archive, whose [Content_Types].xml
+// must declare mainPart.
+func checkZIPContainer(content []byte, mainPart string) error {
+	if err := CheckZIPCorrupted(content); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptedContent, err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptedContent, err)
+	}
+	entry, err := reader.Open("[Content_Types].xml")
+	if err != nil {
+		return fmt.Errorf("%w: archive has no [Content_Types].xml", ErrContentTypeMismatch)
+	}
+	defer entry.Close()
+	contentTypes, err := io.ReadAll(io.LimitReader(entry, maxContentTypesSize))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptedContent, err)
+	}
+	if !bytes.Contains(contentTypes, []byte(`"`+mainPart+`"`)) {
+		return fmt.Errorf("%w: archive has no %s part", ErrContentTypeMismatch, mainPart)
+	}
+	return nil
+}
+
+func checkImage(content []byte, decodeConfig func(io.Reader) (image.Config, error), decode func(io.Reader) (image.Image, error)) error {
+	config, err := decodeConfig(bytes.NewReader(content))
 	if err != nil {
 		return err
 	}
+	if err = checkImageSize(config); err != nil {
+		return err
+	}
 
+	_, err = decode(bytes.NewReader(content))
+	return err
+}
+
+func checkImageSize(config image.Config) error {
+	if config.Width <= 0 || config.Height <= 0 {
+		return fmt.Errorf("invalid image dimensions %dx%d", config.Width, config.Height)
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return fmt.Errorf("image dimensions %dx%d are too large", config.Width, config.Height)
+	}
 	return nil
 }
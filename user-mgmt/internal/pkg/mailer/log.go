@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+)
+
+// LogMailer only logs messages instead of delivering them.
+type LogMailer struct {
+	logger logger.Logger
+	from   string
+}
+
+func NewLogMailer(logger logger.Logger, from string) *LogMailer {
+	return &LogMailer{
+		logger: logger,
+		from:   from,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	m.logger.Infof("email from %s to %s [%s]: %s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
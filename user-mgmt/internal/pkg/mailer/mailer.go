@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+const (
+	TypeSMTP = "smtp"
+	TypeFile = "file"
+	TypeLog  = "log"
+)
+
+func ProvideMailer() fx.Option {
+	return fx.Provide(
+		NewMailer,
+	)
+}
+
+// Message is an email message to be delivered by a Mailer.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages.
+type Mailer interface {
+	// Send delivers the message to its recipient.
+	Send(ctx context.Context, msg *Message) error
+}
+
+type mailerDeps struct {
+	fx.In
+
+	Config *config.Config
+	Logger *logger.LoggingClient
+}
+
+func NewMailer(deps mailerDeps) (Mailer, error) {
+	switch deps.Config.MailerType {
+	case TypeSMTP:
+		return NewSMTPMailer(
+			deps.Config.SMTPHost,
+			deps.Config.SMTPPort,
+			deps.Config.SMTPUser,
+			deps.Config.SMTPPassword,
+			deps.Config.MailerFrom,
+		), nil
+	case TypeFile:
+		return NewFileMailer(deps.Config.MailerFilePath, deps.Config.MailerFrom)
+	}
+	return NewLogMailer(deps.Logger.GetLogger(), deps.Config.MailerFrom), nil
+}
@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the chunks content is streamed in, clamd
+// refuses chunks over its StreamMaxLength.
+const clamdChunkSize = 64 << 10
+
+// ClamdScanner scans content with a ClamAV daemon using the INSTREAM command.
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner for the clamd listening on address, either
+// host:port or unix:/path/to/clamd.sock.
+func NewClamdScanner(address string, timeout time.Duration) *ClamdScanner {
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	}
+	return &ClamdScanner{
+		network: network,
+		address: address,
+		timeout: timeout,
+	}
+}
+
+func (s *ClamdScanner) Name() string {
+	return TypeClamd
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, content []byte) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	reply, err := s.instream(conn, content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return parseReply(reply)
+}
+
+// instream sends content as a sequence of length prefixed chunks, terminated by
+// an empty chunk, and reads the null terminated reply.
+func (s *ClamdScanner) instream(conn net.Conn, content []byte) (string, error) {
+	writer := bufio.NewWriter(conn)
+	if _, err := writer.WriteString("zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+
+	size := make([]byte, 4)
+	for len(content) > 0 {
+		chunk := content
+		if len(chunk) > clamdChunkSize {
+			chunk = chunk[:clamdChunkSize]
+		}
+		binary.BigEndian.PutUint32(size, uint32(len(chunk)))
+		if _, err := writer.Write(size); err != nil {
+			return "", err
+		}
+		if _, err := writer.Write(chunk); err != nil {
+			return "", err
+		}
+		content = content[len(chunk):]
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := writer.Write(size); err != nil {
+		return "", err
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && reply == "" {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseReply parses replies like "stream: OK", "stream: Eicar-Signature FOUND"
+// and "INSTREAM size limit exceeded. ERROR".
+func parseReply(reply string) (*Result, error) {
+	verdict := reply
+	if i := strings.Index(reply, ": "); i >= 0 {
+		verdict = reply[i+2:]
+	}
+
+	switch {
+	case verdict == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrScanFailed, strings.TrimSpace(reply))
+}
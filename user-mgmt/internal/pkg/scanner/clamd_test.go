@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+)
+
+// fakeClamd accepts one connection, reads an INSTREAM command and replies with
+// reply, or never replies when reply is empty. The sizes of the chunks it received
+// and their content are sent on received.
+type fakeClamd struct {
+	listener net.Listener
+	received chan instream
+}
+
+type instream struct {
+	command string
+	chunks  []int
+	content []byte
+	err     error
+}
+
+func newFakeClamd(t *testing.T, reply string) *fakeClamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	clamd := &fakeClamd{listener: listener, received: make(chan instream, 1)}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		received := readInstream(conn)
+		clamd.received <- received
+		if received.err != nil || reply == "" {
+			// hang until the client gives up
+			io.Copy(io.Discard, conn)
+			return
+		}
+		conn.Write([]byte(reply + "\x00"))
+	}()
+	return clamd
+}
+
+func readInstream(conn net.Conn) instream {
+	reader := bufio.NewReader(conn)
+	command, err := reader.ReadString('\x00')
+	if err != nil {
+		return instream{err: err}
+	}
+
+	received := instream{command: command}
+	size := make([]byte, 4)
+	for {
+		if _, err = io.ReadFull(reader, size); err != nil {
+			received.err = err
+			return received
+		}
+		n := binary.BigEndian.Uint32(size)
+		received.chunks = append(received.chunks, int(n))
+		if n == 0 {
+			return received
+		}
+		chunk := make([]byte, n)
+		if _, err = io.ReadFull(reader, chunk); err != nil {
+			received.err = err
+			return received
+		}
+		received.content = append(received.content, chunk...)
+	}
+}
+
+func TestClamdStreamsContentInChunks(t *testing.T) {
+	clamd := newFakeClamd(t, "stream: OK")
+	scanner := NewClamdScanner(clamd.listener.Addr().String(), time.Second)
+
+	content := bytes.Repeat([]byte("a"), 2*clamdChunkSize+100)
+	result, err := scanner.Scan(context.Background(), content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Infected {
+		t.Errorf("expected clean content, got %+v", result)
+	}
+
+	received := <-clamd.received
+	if received.err != nil {
+		t.Fatalf("fake clamd could not read the stream: %v", received.err)
+	}
+	if received.command != "zINSTREAM\x00" {
+		t.Errorf("expected the zINSTREAM command, got %q", received.command)
+	}
+	wantChunks := []int{clamdChunkSize, clamdChunkSize, 100, 0}
+	if len(received.chunks) != len(wantChunks) {
+		t.Fatalf("expected chunks %v, got %v", wantChunks, received.chunks)
+	}
+	for i := range wantChunks {
+		if received.chunks[i] != wantChunks[i] {
+			t.Errorf("expected chunks %v, got %v", wantChunks, received.chunks)
+			break
+		}
+	}
+	if !bytes.Equal(received.content, content) {
+		t.Errorf("expected the streamed content to match")
+	}
+}
+
+func TestClamdReportsFoundSignature(t *testing.T) {
+	clamd := newFakeClamd(t, "stream: Eicar-Signature FOUND")
+	scanner := NewClamdScanner(clamd.listener.Addr().String(), time.Second)
+
+	result, err := scanner.Scan(context.Background(), eicarSignature)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Infected || result.Signature != "Eicar-Signature" {
+		t.Errorf("expected Eicar-Signature to be found, got %+v", result)
+	}
+}
+
+func TestClamdReportsErrorReplies(t *testing.T) {
+	clamd := newFakeClamd(t, "INSTREAM size limit exceeded. ERROR")
+	scanner := NewClamdScanner(clamd.listener.Addr().String(), time.Second)
+
+	_, err := scanner.Scan(context.Background(), []byte("content"))
+	if !errors.Is(err, ErrScanFailed) {
+		t.Errorf("expected %v, got %v", ErrScanFailed, err)
+	}
+}
+
+func TestClamdTimesOut(t *testing.T) {
+	clamd := newFakeClamd(t, "")
+	scanner := NewClamdScanner(clamd.listener.Addr().String(), 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := scanner.Scan(context.Background(), []byte("content"))
+	if !errors.Is(err, ErrScanFailed) {
+		t.Errorf("expected %v, got %v", ErrScanFailed, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the scan to give up after its timeout, took %s", elapsed)
+	}
+}
+
+func TestNewScannerRefusesUnknownTypes(t *testing.T) {
+	deps := scannerDeps{
+		Config: &config.Config{ScannerType: "clamav"},
+		Logger: &logger.LoggingClient{},
+	}
+	if _, err := NewScanner(deps); err == nil {
+		t.Errorf("expected unknown scanner type %q to be refused", deps.Config.ScannerType)
+	}
+}
@@ -0,0 +1,28 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+)
+
+// eicarSignature is the EICAR anti-virus test file, which every scanner detects.
+var eicarSignature = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// EicarScanner only detects the EICAR test file. It allows exercising the
+// quarantine without a real anti-virus.
+type EicarScanner struct{}
+
+func NewEicarScanner() *EicarScanner {
+	return &EicarScanner{}
+}
+
+func (s *EicarScanner) Name() string {
+	return TypeEicar
+}
+
+func (s *EicarScanner) Scan(ctx context.Context, content []byte) (*Result, error) {
+	if bytes.Contains(content, eicarSignature) {
+		return &Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return &Result{}, nil
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"go.uber.org/fx"
+)
+
+const (
+	TypeClamd = "clamd"
+	TypeEicar = "eicar"
+	TypeNone  = "none"
+)
+
+// ErrScanFailed is returned when content could not be scanned.
+var ErrScanFailed = errors.New("malware scan failed")
+
+func ProvideScanner() fx.Option {
+	return fx.Provide(
+		NewScanner,
+	)
+}
+
+// Result is the verdict of a scan.
+type Result struct {
+	Infected bool
+	// Signature names the detected malware, if any.
+	Signature string
+}
+
+// Scanner scans file content for malware.
+type Scanner interface {
+	// Name identifies the scanner in logs.
+	Name() string
+	// Scan scans content. Errors wrap ErrScanFailed.
+	Scan(ctx context.Context, content []byte) (*Result, error)
+}
+
+type scannerDeps struct {
+	fx.In
+
+	Config *config.Config
+	Logger *logger.LoggingClient
+}
+
+// NewScanner creates the configured scanner. Unknown types are refused rather than
+// replaced by a weaker scanner, so that a typo cannot disable malware scanning.
+func NewScanner(deps scannerDeps) (Scanner, error) {
+	switch deps.Config.ScannerType {
+	case TypeClamd:
+		return NewClamdScanner(deps.Config.ClamdAddress, deps.Config.ScannerTimeout), nil
+	case TypeEicar:
+		deps.Logger.GetLogger().Warning("scanning files for the EICAR test file only, do not use in production")
+		return NewEicarScanner(), nil
+	case TypeNone:
+		deps.Logger.GetLogger().Warning("files are not scanned for malware")
+		return NewNoopScanner(), nil
+	}
+	return nil, fmt.Errorf("unknown scanner type %q, expected %s, %s or %s", deps.Config.ScannerType, TypeClamd, TypeEicar, TypeNone)
+}
+
+// NoopScanner reports every content as clean.
+type NoopScanner struct{}
+
+func NewNoopScanner() *NoopScanner {
+	return &NoopScanner{}
+}
+
+func (s *NoopScanner) Name() string {
+	return TypeNone
+}
+
+func (s *NoopScanner) Scan(ctx context.Context, content []byte) (*Result, error) {
+	return &Result{}, nil
+}
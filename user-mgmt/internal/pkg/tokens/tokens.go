@@ -0,0 +1,131 @@
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"go.uber.org/fx"
+)
+
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposeFileShare         = "file_share"
+)
+
+var (
+	ErrMalformedToken = errors.New("malformed token")
+	ErrInvalidToken   = errors.New("invalid token signature")
+	ErrExpiredToken   = errors.New("token has expired")
+	ErrWrongPurpose   = errors.New("token issued for a different purpose")
+	ErrUnknownPurpose = errors.New("no secret for token purpose")
+)
+
+func ProvideTokenSigner() fx.Option {
+	return fx.Provide(
+		NewTokenSigner,
+	)
+}
+
+// Claims are the signed contents of a token.
+type Claims struct {
+	Purpose   string `json:"pur"`
+	Subject   string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenSigner issues and verifies HMAC-SHA256 signed, expiring tokens. Each purpose
+// has a secret of its own, so rotating one does not invalidate the other tokens.
+type TokenSigner struct {
+	secrets map[string][]byte
+	ttl     time.Duration
+}
+
+type tokenSignerDeps struct {
+	fx.In
+
+	Config *config.Config
+}
+
+func NewTokenSigner(deps tokenSignerDeps) *TokenSigner {
+	return &TokenSigner{
+		secrets: map[string][]byte{
+			PurposeEmailVerification: []byte(deps.Config.VerificationTokenSecret),
+			PurposeFileShare:         []byte(deps.Config.ShareTokenSecret),
+		},
+		ttl: deps.Config.VerificationTokenTTL,
+	}
+}
+
+// Sign issues a token for the given claims, expiring after the configured ttl.
+func (s *TokenSigner) Sign(claims Claims) (string, error) {
+	return s.SignUntil(claims, time.Now().Add(s.ttl))
+}
+
+// SignUntil issues a token for the given claims, expiring at expiresAt.
+func (s *TokenSigner) SignUntil(claims Claims, expiresAt time.Time) (string, error) {
+	secret, ok := s.secrets[claims.Purpose]
+	if !ok {
+		return "", ErrUnknownPurpose
+	}
+
+	claims.ExpiresAt = expiresAt.Unix()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(secret, encoded)), nil
+}
+
+// Verify checks the token signature, expiry and purpose and returns its claims.
+// Tokens of other purposes are signed with another secret, their signature is invalid.
+func (s *TokenSigner) Verify(token, purpose string) (*Claims, error) {
+	secret, ok := s.secrets[purpose]
+	if !ok {
+		return nil, ErrUnknownPurpose
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !hmac.Equal(signature, sign(secret, parts[0])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	claims := &Claims{}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if claims.Purpose != purpose {
+		return nil, ErrWrongPurpose
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
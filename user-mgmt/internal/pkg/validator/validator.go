@@ -1,6 +1,9 @@
 package validator
 
 import (
+	"reflect"
+	"strings"
+
 	validator "github.com/go-playground/validator/v10"
 	"go.uber.org/fx"
 )
@@ -10,5 +13,14 @@ func ProvideValidator() fx.Option {
 }
 
 func NewValidator() *validator.Validate {
-	return validator.New()
+	validate := validator.New()
+	// report fields by the names clients use
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return validate
 }
@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the timestamp and signature of a webhook payload,
+	// formatted as "t=<unix timestamp>,v1=<hex hmac-sha256>".
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader carries the type of the delivered event.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader carries the unique id of the delivery.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+var (
+	ErrMalformedSignature = errors.New("malformed webhook signature header")
+	ErrSignatureMismatch  = errors.New("webhook signature mismatch")
+	ErrSignatureExpired   = errors.New("webhook signature timestamp outside of tolerance")
+)
+
+// Sign computes the signature header value of body for the given timestamp. The
+// signed content is "<unix timestamp>.<body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(mac(secret, ts, body)))
+}
+
+// Verify checks a signature header value against body, rejecting timestamps
+// older or newer than tolerance to prevent replays.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, signature string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrMalformedSignature
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			signature = kv[1]
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || signature == "" {
+		return ErrMalformedSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+	if !hmac.Equal(expected, mac(secret, ts, body)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
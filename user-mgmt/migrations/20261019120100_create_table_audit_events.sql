@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_events (
+    id              INT NOT NULL AUTO_INCREMENT,
+    event_id        VARCHAR(255) NOT NULL,
+    actor           VARCHAR(255) NOT NULL,
+    action          VARCHAR(255) NOT NULL,
+    target_type     VARCHAR(255) NOT NULL,
+    target_id       VARCHAR(255) NOT NULL,
+    request_id      VARCHAR(255) NULL,
+    client_ip       VARCHAR(255) NULL,
+    before_snapshot JSON NULL,
+    after_snapshot  JSON NULL,
+    created_at      DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_audit_events_actor (actor),
+    INDEX idx_audit_events_action (action),
+    INDEX idx_audit_events_target (target_type, target_id),
+    INDEX idx_audit_events_created_at (created_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_events;
+-- +goose StatementEnd
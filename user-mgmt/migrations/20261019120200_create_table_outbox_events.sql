@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              INT NOT NULL AUTO_INCREMENT,
+    event_id        VARCHAR(255) NOT NULL,
+    event_type      VARCHAR(255) NOT NULL,
+    aggregate_type  VARCHAR(255) NOT NULL,
+    aggregate_id    VARCHAR(255) NOT NULL,
+    payload         JSON NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT NULL,
+    next_attempt_at DATETIME(3) NOT NULL,
+    published_at    DATETIME(3) NULL,
+    created_at      DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_outbox_events_event_id (event_id),
+    INDEX idx_outbox_events_pending (published_at, next_attempt_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox_events;
+-- +goose StatementEnd
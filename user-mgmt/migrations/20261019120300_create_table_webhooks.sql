@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id              INT NOT NULL AUTO_INCREMENT,
+    subscription_id VARCHAR(255) NOT NULL,
+    url             VARCHAR(2048) NOT NULL,
+    secret          VARCHAR(255) NOT NULL,
+    event_types     VARCHAR(1024) NOT NULL DEFAULT '',
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      DATETIME(3) NULL,
+    updated_at      DATETIME(3) NULL,
+    deleted_at      DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_webhook_subscriptions_subscription_id (subscription_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              INT NOT NULL AUTO_INCREMENT,
+    delivery_id     VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    event_id        VARCHAR(255) NOT NULL,
+    event_type      VARCHAR(255) NOT NULL,
+    payload         JSON NOT NULL,
+    status          VARCHAR(32) NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    response_status INT NULL,
+    last_error      TEXT NULL,
+    next_attempt_at DATETIME(3) NOT NULL,
+    delivered_at    DATETIME(3) NULL,
+    created_at      DATETIME(3) NULL,
+    updated_at      DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_webhook_deliveries_delivery_id (delivery_id),
+    INDEX idx_webhook_deliveries_subscription_id (subscription_id),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook_subscriptions;
+-- +goose StatementEnd
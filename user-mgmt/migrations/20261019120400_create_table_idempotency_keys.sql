@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id                    INT NOT NULL AUTO_INCREMENT,
+    actor                 VARCHAR(255) NOT NULL,
+    idempotency_key       VARCHAR(255) NOT NULL,
+    fingerprint           CHAR(64) NOT NULL,
+    status                VARCHAR(32) NOT NULL,
+    response_status       INT NULL,
+    response_content_type VARCHAR(255) NULL,
+    response_body         LONGBLOB NULL,
+    expires_at            DATETIME(3) NOT NULL,
+    created_at            DATETIME(3) NULL,
+    updated_at            DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_idempotency_keys_actor_key (actor, idempotency_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS jobs (
+    id               INT NOT NULL AUTO_INCREMENT,
+    job_id           VARCHAR(255) NOT NULL,
+    type             VARCHAR(64) NOT NULL,
+    user_id          VARCHAR(255) NOT NULL,
+    source_url       VARCHAR(2048) NULL,
+    actor            VARCHAR(255) NOT NULL,
+    request_id       VARCHAR(255) NULL,
+    status           VARCHAR(32) NOT NULL,
+    progress         INT NOT NULL DEFAULT 0,
+    attempts         INT NOT NULL DEFAULT 0,
+    result_id        VARCHAR(255) NULL,
+    error            TEXT NULL,
+    error_code       INT NULL,
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    lease_owner      VARCHAR(255) NULL,
+    lease_expires_at DATETIME(3) NULL,
+    started_at       DATETIME(3) NULL,
+    finished_at      DATETIME(3) NULL,
+    created_at       DATETIME(3) NULL,
+    updated_at       DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_jobs_job_id (job_id),
+    INDEX idx_jobs_claim (status, lease_expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE jobs;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_files ADD COLUMN file_size BIGINT NOT NULL DEFAULT 0 AFTER file_type;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE user_files SET file_size = LENGTH(file_content);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_user_files_user_id ON user_files (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_files_user_id ON user_files;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files DROP COLUMN file_size;
+-- +goose StatementEnd
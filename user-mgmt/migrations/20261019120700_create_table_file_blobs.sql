@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS file_blobs (
+    id         INT NOT NULL AUTO_INCREMENT,
+    sha256     CHAR(64) NOT NULL,
+    size       BIGINT NOT NULL,
+    content    LONGBLOB NOT NULL,
+    ref_count  BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_file_blobs_sha256 (sha256)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files
+    ADD COLUMN sha256 CHAR(64) NULL AFTER file_size,
+    MODIFY COLUMN file_content LONGBLOB NULL,
+    ADD INDEX idx_user_files_sha256 (sha256);
+-- +goose StatementEnd
+
+-- move the content of existing files to blobs, one per distinct digest
+-- +goose StatementBegin
+UPDATE user_files SET sha256 = SHA2(file_content, 256);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO file_blobs (sha256, size, content, ref_count, created_at, updated_at)
+SELECT sha256, MAX(file_size), MAX(file_content), COUNT(*), NOW(3), NOW(3)
+FROM user_files
+GROUP BY sha256;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE user_files SET file_content = NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE user_files
+JOIN file_blobs ON file_blobs.sha256 = user_files.sha256
+SET user_files.file_content = file_blobs.content;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files
+    DROP INDEX idx_user_files_sha256,
+    DROP COLUMN sha256,
+    MODIFY COLUMN file_content LONGBLOB NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE file_blobs;
+-- +goose StatementEnd
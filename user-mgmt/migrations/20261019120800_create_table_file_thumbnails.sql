@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS file_thumbnails (
+    id           INT NOT NULL AUTO_INCREMENT,
+    sha256       CHAR(64) NOT NULL,
+    size         INT NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    content      MEDIUMBLOB NOT NULL,
+    created_at   DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_file_thumbnails_sha256_size (sha256, size)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE file_thumbnails;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_file_metadata (
+    id             INT NOT NULL AUTO_INCREMENT,
+    file_id        VARCHAR(255) NOT NULL,
+    page_count     INT NOT NULL DEFAULT 0,
+    title          VARCHAR(1024) NOT NULL DEFAULT '',
+    author         VARCHAR(1024) NOT NULL DEFAULT '',
+    pdf_created_at DATETIME(3) NULL,
+    encrypted      BOOLEAN NOT NULL DEFAULT FALSE,
+    pdf_version    VARCHAR(16) NOT NULL DEFAULT '',
+    created_at     DATETIME(3) NULL,
+    updated_at     DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_user_file_metadata_file_id (file_id),
+    INDEX idx_user_file_metadata_page_count (page_count)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_user_files_file_id ON user_files (file_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_files_file_id ON user_files;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE user_file_metadata;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_file_texts (
+    id         INT NOT NULL AUTO_INCREMENT,
+    file_id    VARCHAR(255) NOT NULL,
+    user_id    VARCHAR(255) NOT NULL,
+    content    LONGTEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_user_file_texts_file_id (file_id),
+    INDEX idx_user_file_texts_user_id (user_id),
+    FULLTEXT INDEX idx_user_file_texts_content (content)
+) ENGINE = InnoDB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_file_texts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_files
+    ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'available' AFTER file_size,
+    ADD COLUMN quarantine_reason VARCHAR(255) NULL AFTER status;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_files
+    DROP COLUMN quarantine_reason,
+    DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- existing content stays readable in plaintext until encrypted by a key rotation
+-- +goose StatementBegin
+ALTER TABLE file_blobs
+    ADD COLUMN key_id      VARCHAR(255) NULL AFTER content,
+    ADD COLUMN wrapped_key VARBINARY(255) NULL AFTER key_id,
+    ADD INDEX idx_file_blobs_key_id (key_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE file_thumbnails
+    ADD COLUMN key_id      VARCHAR(255) NULL AFTER content,
+    ADD COLUMN wrapped_key VARBINARY(255) NULL AFTER key_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- encrypted content can not be read once the keys are dropped, decrypt it first
+-- +goose StatementBegin
+DELETE FROM file_thumbnails WHERE key_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE file_thumbnails
+    DROP COLUMN wrapped_key,
+    DROP COLUMN key_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE file_blobs
+    DROP INDEX idx_file_blobs_key_id,
+    DROP COLUMN wrapped_key,
+    DROP COLUMN key_id;
+-- +goose StatementEnd
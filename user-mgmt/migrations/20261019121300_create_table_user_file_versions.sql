@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_file_versions (
+    id                INT NOT NULL AUTO_INCREMENT,
+    file_id           VARCHAR(255) NOT NULL,
+    version           INT NOT NULL,
+    file_type         VARCHAR(255) NOT NULL,
+    file_size         BIGINT NOT NULL DEFAULT 0,
+    sha256            CHAR(64) NOT NULL,
+    status            VARCHAR(32) NOT NULL DEFAULT 'available',
+    quarantine_reason VARCHAR(255) NULL,
+    created_at        DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_user_file_versions_file_id_version (file_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files
+    ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER sha256;
+-- +goose StatementEnd
+
+-- every existing file becomes its first version, which takes over its reference to the blob
+-- +goose StatementBegin
+INSERT INTO user_file_versions (file_id, version, file_type, file_size, sha256, status, quarantine_reason, created_at)
+SELECT file_id, 1, file_type, file_size, sha256, status, quarantine_reason, created_at
+FROM user_files;
+-- +goose StatementEnd
+
+-- +goose Down
+-- older versions hold blob references that are lost with them
+-- +goose StatementBegin
+UPDATE file_blobs
+JOIN (
+    SELECT user_file_versions.sha256, COUNT(*) AS refs
+    FROM user_file_versions
+    JOIN user_files ON user_files.file_id = user_file_versions.file_id
+    WHERE user_file_versions.version <> user_files.version
+    GROUP BY user_file_versions.sha256
+) released ON released.sha256 = file_blobs.sha256
+SET file_blobs.ref_count = file_blobs.ref_count - released.refs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files
+    DROP COLUMN version;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE user_file_versions;
+-- +goose StatementEnd
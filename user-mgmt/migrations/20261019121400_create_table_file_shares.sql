@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS file_shares (
+    id             INT NOT NULL AUTO_INCREMENT,
+    share_id       VARCHAR(255) NOT NULL,
+    file_id        VARCHAR(255) NOT NULL,
+    user_id        VARCHAR(255) NOT NULL,
+    created_by     VARCHAR(255) NOT NULL,
+    expires_at     DATETIME(3) NOT NULL,
+    max_downloads  INT NULL,
+    download_count INT NOT NULL DEFAULT 0,
+    revoked_at     DATETIME(3) NULL,
+    created_at     DATETIME(3) NULL,
+    updated_at     DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_file_shares_share_id (share_id),
+    INDEX idx_file_shares_file_id (file_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE file_shares;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_files
+    ADD COLUMN filename    VARCHAR(255) NULL AFTER file_id,
+    ADD COLUMN description TEXT NULL AFTER filename;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_file_tags (
+    id         INT NOT NULL AUTO_INCREMENT,
+    file_id    VARCHAR(255) NOT NULL,
+    tag        VARCHAR(64) NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_user_file_tags_file_id_tag (file_id, tag),
+    INDEX idx_user_file_tags_tag (tag)
+);
+-- +goose StatementEnd
+
+-- queued jobs keep the whole request, not only its url
+-- +goose StatementBegin
+ALTER TABLE jobs
+    ADD COLUMN payload TEXT NULL AFTER source_url;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE jobs
+    DROP COLUMN payload;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE user_file_tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE user_files
+    DROP COLUMN description,
+    DROP COLUMN filename;
+-- +goose StatementEnd
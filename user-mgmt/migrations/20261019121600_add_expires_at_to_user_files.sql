@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_files
+    ADD COLUMN expires_at DATETIME(3) NULL AFTER version,
+    ADD INDEX idx_user_files_expires_at (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_files
+    DROP INDEX idx_user_files_expires_at,
+    DROP COLUMN expires_at;
+-- +goose StatementEnd
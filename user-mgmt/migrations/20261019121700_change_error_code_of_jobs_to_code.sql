@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE jobs
+    MODIFY COLUMN error_code VARCHAR(64) NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE jobs
+SET error_code = CASE error_code
+    WHEN '400' THEN 'invalid_request'
+    WHEN '404' THEN 'user_not_found'
+    WHEN '413' THEN 'file_too_large'
+    WHEN '415' THEN 'file_type_not_allowed'
+    WHEN '502' THEN 'file_server_failure'
+    WHEN '503' THEN 'file_server_unavailable'
+    WHEN '504' THEN 'file_server_timeout'
+    WHEN '507' THEN 'quota_exceeded'
+    ELSE 'internal_error'
+END
+WHERE error_code IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE jobs
+SET error_code = CASE error_code
+    WHEN 'invalid_request' THEN '400'
+    WHEN 'user_not_found' THEN '404'
+    WHEN 'file_too_large' THEN '413'
+    WHEN 'file_type_not_allowed' THEN '415'
+    WHEN 'file_server_failure' THEN '502'
+    WHEN 'file_server_unavailable' THEN '503'
+    WHEN 'file_server_timeout' THEN '504'
+    WHEN 'quota_exceeded' THEN '507'
+    ELSE '500'
+END
+WHERE error_code IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE jobs
+    MODIFY COLUMN error_code INT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- existing secrets stay readable in plaintext until encrypted by a key rotation
+-- +goose StatementBegin
+ALTER TABLE webhook_subscriptions
+    MODIFY COLUMN secret VARBINARY(512) NOT NULL,
+    ADD COLUMN secret_key_id      VARCHAR(255) NULL AFTER secret,
+    ADD COLUMN secret_wrapped_key VARBINARY(255) NULL AFTER secret_key_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- encrypted secrets can not be read once the keys are dropped, their subscriptions
+-- are deactivated and must be created again
+-- +goose StatementBegin
+UPDATE webhook_subscriptions
+SET secret = '', active = FALSE
+WHERE secret_key_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhook_subscriptions
+    DROP COLUMN secret_wrapped_key,
+    DROP COLUMN secret_key_id,
+    MODIFY COLUMN secret VARCHAR(255) NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- error_code holds the response status of the error again, as it did before codes
+-- were introduced, and the stable code of the error moves to its own column
+-- +goose StatementBegin
+ALTER TABLE jobs
+    CHANGE COLUMN error_code code VARCHAR(64) NULL,
+    ADD COLUMN error_code INT NULL AFTER code;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE jobs
+SET error_code = CASE code
+    WHEN 'invalid_request' THEN 400
+    WHEN 'user_not_found' THEN 404
+    WHEN 'file_too_large' THEN 413
+    WHEN 'file_type_not_allowed' THEN 415
+    WHEN 'file_server_failure' THEN 502
+    WHEN 'file_server_unavailable' THEN 503
+    WHEN 'file_server_timeout' THEN 504
+    WHEN 'quota_exceeded' THEN 507
+    ELSE 500
+END
+WHERE code IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE jobs
+    DROP COLUMN error_code,
+    CHANGE COLUMN code error_code VARCHAR(64) NULL;
+-- +goose StatementEnd
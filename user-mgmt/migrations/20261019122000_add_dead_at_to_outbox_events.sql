@@ -0,0 +1,12 @@
+-- +goose Up
+-- events that failed too many times are dead and no longer relayed
+-- +goose StatementBegin
+ALTER TABLE outbox_events
+    ADD COLUMN dead_at DATETIME(3) NULL AFTER published_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox_events
+    DROP COLUMN dead_at;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- a delivery is claimed by a single dispatcher while it is attempted
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries
+    ADD COLUMN lease_owner VARCHAR(255) NULL AFTER next_attempt_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries
+    DROP COLUMN lease_owner;
+-- +goose StatementEnd
@@ -0,0 +1,219 @@
+// Package client is a Go client of the users REST API. It covers the users, their
+// files with their versions and shares, and the jobs of asynchronous file creations.
+//
+// Failed requests return an *Error decoded from the problem details of the response.
+// Requests are retried with exponential backoff on connection errors, 429 and 5xx
+// responses, POST requests being sent with an Idempotency-Key so that retries are
+// safe.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pedromspeixoto/users-api/internal/pkg/backoff"
+)
+
+const (
+	// ActorHeader identifies who performs a request in the audit log.
+	ActorHeader = "X-Actor-Id"
+	// IdempotencyKeyHeader makes retries of POST requests safe.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 200 * time.Millisecond
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client calls the users API. It is safe for concurrent use.
+type Client struct {
+	baseUrl      string
+	actor        string
+	maxRetries   int
+	retryBackoff time.Duration
+	httpClient
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHttpClient sets the client sending the requests, http.DefaultClient by default.
+func WithHttpClient(httpClient httpClient) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithActor sets the actor sent with every request, recorded in the audit log.
+func WithActor(actor string) Option {
+	return func(c *Client) {
+		c.actor = actor
+	}
+}
+
+// WithRetries sets how many times failed requests are retried, 0 disabling retries,
+// and the base delay between attempts which doubles after each of them.
+func WithRetries(maxRetries int, retryBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = retryBackoff
+	}
+}
+
+// New returns a client of the API served at baseUrl, e.g. http://localhost:8080.
+func New(baseUrl string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseUrl)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid users api url %q", baseUrl)
+	}
+
+	client := &Client{
+		baseUrl:      strings.TrimSuffix(baseUrl, "/"),
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		httpClient:   http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client, nil
+}
+
+// envelope is the body of successful JSON responses.
+type envelope struct {
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// call sends a request and decodes the data of its response into out, unless out is nil.
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&envelope{Data: out}); err != nil {
+		return fmt.Errorf("users api: could not decode response of %s %s: %v", method, path, err)
+	}
+	return nil
+}
+
+// send sends a request, retrying transient failures, and returns its successful
+// response whose body must be closed.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var (
+		payload []byte
+		err     error
+	)
+	if body != nil {
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("users api: could not encode request of %s %s: %v", method, path, err)
+		}
+	}
+
+	// the same key is sent with every attempt, so that the request is only performed once
+	var idempotencyKey string
+	if method == http.MethodPost {
+		if idempotencyKey, err = newIdempotencyKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, method, path, query, payload, idempotencyKey)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt > c.maxRetries || ctx.Err() != nil || !retryable(err) {
+			return nil, err
+		}
+
+		delay := backoff.Exponential(attempt, c.retryBackoff, 10*c.retryBackoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, query url.Values, payload []byte, idempotencyKey string) (*http.Response, error) {
+	endpoint := c.baseUrl + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.actor != "" {
+		req.Header.Set(ActorHeader, c.actor)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// newIdempotencyKey returns a random key identifying a request and its retries.
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("users api: could not generate idempotency key: %v", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// retryable reports whether err is a transient failure worth retrying: connection
+// errors, 429 and 5xx responses, and requests whose idempotency key is still in use.
+// Exceeded quotas are reported with a 5xx status but do not go away by retrying.
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch {
+	case apiErr.Code == CodeIdempotencyKeyInUse, apiErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case apiErr.StatusCode == http.StatusInsufficientStorage:
+		return false
+	default:
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+}
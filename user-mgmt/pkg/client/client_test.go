@@ -0,0 +1,337 @@
+package client_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pedromspeixoto/users-api/internal/config"
+	"github.com/pedromspeixoto/users-api/internal/data/models/idempotency"
+	"github.com/pedromspeixoto/users-api/internal/domain/apperrors"
+	"github.com/pedromspeixoto/users-api/internal/domain/audit"
+	"github.com/pedromspeixoto/users-api/internal/domain/health"
+	"github.com/pedromspeixoto/users-api/internal/domain/jobs"
+	"github.com/pedromspeixoto/users-api/internal/domain/search"
+	"github.com/pedromspeixoto/users-api/internal/domain/shares"
+	"github.com/pedromspeixoto/users-api/internal/domain/users"
+	"github.com/pedromspeixoto/users-api/internal/domain/webhooks"
+	"github.com/pedromspeixoto/users-api/internal/dto"
+	usersdto "github.com/pedromspeixoto/users-api/internal/dto/users"
+	apihttp "github.com/pedromspeixoto/users-api/internal/http"
+	"github.com/pedromspeixoto/users-api/internal/http/handlers"
+	"github.com/pedromspeixoto/users-api/internal/pkg/logger"
+	"github.com/pedromspeixoto/users-api/internal/pkg/validator"
+	"github.com/pedromspeixoto/users-api/pkg/client"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// fakeUserService implements the calls of the tests, the others panic.
+type fakeUserService struct {
+	users.UserService
+
+	mu          sync.Mutex
+	users       []usersdto.UserResponse
+	createCalls int
+	createFails int
+	download    *usersdto.UserFileDownload
+}
+
+func (s *fakeUserService) CreateUser(ctx context.Context, request *usersdto.UserRequest) (*usersdto.UserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createCalls++
+	if s.createCalls <= s.createFails {
+		return nil, apperrors.Internal(errors.New("database is down"), "unexpected error creating user")
+	}
+	return &usersdto.UserResponse{UserId: "new-user", Email: request.Email}, nil
+}
+
+func (s *fakeUserService) GetUser(ctx context.Context, uuid string) (*usersdto.UserResponse, error) {
+	for i := range s.users {
+		if s.users[i].UserId == uuid {
+			return &s.users[i], nil
+		}
+	}
+	return nil, apperrors.NotFound(apperrors.CodeUserNotFound, "user %s not found", uuid)
+}
+
+func (s *fakeUserService) ListUsers(ctx context.Context, pagination *dto.PaginationRequest) (*dto.PaginationResponse, error) {
+	start := (pagination.Page - 1) * pagination.Limit
+	end := start + pagination.Limit
+	if start > len(s.users) {
+		start = len(s.users)
+	}
+	if end > len(s.users) {
+		end = len(s.users)
+	}
+	return &dto.PaginationResponse{
+		CurrentPage: pagination.Page,
+		TotalRows:   int64(len(s.users)),
+		TotalPages:  (len(s.users) + pagination.Limit - 1) / pagination.Limit,
+		Data:        &usersdto.UserListResponse{Users: s.users[start:end]},
+	}, nil
+}
+
+func (s *fakeUserService) DownloadUserFile(ctx context.Context, userId string, uuid string) (*usersdto.UserFileDownload, error) {
+	return s.download, nil
+}
+
+// healthyService reports the database as up for the checks of the health handler.
+type healthyService struct{}
+
+func (healthyService) GetDbStatus() error {
+	return nil
+}
+
+// memoryKeys is an in memory repository of idempotency keys.
+type memoryKeys struct {
+	mu   sync.Mutex
+	keys map[string]*idempotency.IdempotencyKey
+}
+
+func (m *memoryKeys) Get(actor, key string) (*idempotency.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.keys[actor+"/"+key]; ok {
+		copied := *stored
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryKeys) Create(key *idempotency.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key.Actor+"/"+key.IdempotencyKey]; ok {
+		return idempotency.ErrKeyExists
+	}
+	copied := *key
+	m.keys[key.Actor+"/"+key.IdempotencyKey] = &copied
+	return nil
+}
+
+func (m *memoryKeys) Update(key *idempotency.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *key
+	m.keys[key.Actor+"/"+key.IdempotencyKey] = &copied
+	return nil
+}
+
+func (m *memoryKeys) Delete(key *idempotency.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key.Actor+"/"+key.IdempotencyKey)
+	return nil
+}
+
+func (m *memoryKeys) DeleteExpired() error {
+	return nil
+}
+
+// newServer serves the real router of the API with userService, and records the
+// idempotency keys of the requests it receives.
+func newServer(t *testing.T, userService *fakeUserService) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var router chi.Router
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(&config.Config{
+			LoggerType:           logger.TypeStdout,
+			LoggerLevel:          logger.LoggingLevelNone,
+			IdempotencyKeyTTL:    time.Hour,
+			ThumbnailDefaultSize: 128,
+		}),
+		logger.ProvideLogger(),
+		validator.ProvideValidator(),
+		handlers.ProvideHandlers(),
+		fx.Provide(
+			func() users.UserService { return userService },
+			func() idempotency.IdempotencyKeyRepository {
+				return &memoryKeys{keys: map[string]*idempotency.IdempotencyKey{}}
+			},
+			func() audit.AuditService { return nil },
+			func() health.HealthService { return healthyService{} },
+			func() jobs.JobService { return nil },
+			func() search.SearchService { return nil },
+			func() shares.ShareService { return nil },
+			func() webhooks.WebhookService { return nil },
+		),
+		fx.Populate(&router),
+		fx.Provide(apihttp.NewRouter),
+	)
+	if err := app.Err(); err != nil {
+		t.Fatalf("could not build router: %v", err)
+	}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mu.Lock()
+			keys = append(keys, r.Header.Get(client.IdempotencyKeyHeader))
+			mu.Unlock()
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &keys
+}
+
+func newClient(t *testing.T, server *httptest.Server) *client.Client {
+	t.Helper()
+	c, err := client.New(server.URL, client.WithActor("tester"), client.WithRetries(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+	return c
+}
+
+func TestErrorsAreDecodedFromProblems(t *testing.T) {
+	server, _ := newServer(t, &fakeUserService{})
+	c := newClient(t, server)
+
+	_, err := c.GetUser(context.Background(), "unknown")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *client.Error, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != client.CodeUserNotFound {
+		t.Errorf("expected 404 %s, got %d %s", client.CodeUserNotFound, apiErr.StatusCode, apiErr.Code)
+	}
+	if apiErr.RequestId == "" || apiErr.Detail == "" {
+		t.Errorf("expected request id and detail, got %+v", apiErr)
+	}
+	if !client.IsNotFound(err) {
+		t.Errorf("expected IsNotFound to report %v", err)
+	}
+
+	_, err = c.CreateUser(context.Background(), &client.CreateUserRequest{Email: "not an email"})
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *client.Error, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != client.CodeValidationFailed {
+		t.Errorf("expected 400 %s, got %d %s", client.CodeValidationFailed, apiErr.StatusCode, apiErr.Code)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "email" || apiErr.Fields[0].Rule != "email" {
+		t.Errorf("expected the email field to fail the email rule, got %+v", apiErr.Fields)
+	}
+}
+
+func TestIteratorCrossesPages(t *testing.T) {
+	userService := &fakeUserService{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		userService.users = append(userService.users, usersdto.UserResponse{UserId: id, Email: id + "@example.com"})
+	}
+	server, _ := newServer(t, userService)
+	c := newClient(t, server)
+
+	var ids []string
+	it := c.Users(context.Background(), &client.ListOptions{Limit: 2})
+	for it.Next() {
+		ids = append(ids, it.Item().UserId)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := len(ids), len(userService.users); got != want {
+		t.Fatalf("expected %d users, got %d: %v", want, got, ids)
+	}
+	for i, id := range ids {
+		if id != userService.users[i].UserId {
+			t.Errorf("expected user %d to be %s, got %s", i, userService.users[i].UserId, id)
+		}
+	}
+}
+
+func TestDownloadIsCheckedAgainstItsDigest(t *testing.T) {
+	content := []byte("%PDF-1.7 content")
+	sum := sha256.Sum256(content)
+	userService := &fakeUserService{download: &usersdto.UserFileDownload{
+		Content:  content,
+		Filename: "report.pdf",
+		Sha256:   hex.EncodeToString(sum[:]),
+	}}
+	server, _ := newServer(t, userService)
+	c := newClient(t, server)
+
+	download, err := c.DownloadUserFile(context.Background(), "user", "file")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := io.ReadAll(download)
+	download.Close()
+	if err != nil || string(got) != string(content) {
+		t.Errorf("expected the content, got %q and %v", got, err)
+	}
+	if download.Filename != "report.pdf" || download.Sha256 != userService.download.Sha256 {
+		t.Errorf("unexpected download metadata %+v", download)
+	}
+
+	// the service sends the digest of other content
+	other := sha256.Sum256([]byte("other content"))
+	userService.download.Sha256 = hex.EncodeToString(other[:])
+	download, err = c.DownloadUserFile(context.Background(), "user", "file")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = io.ReadAll(download)
+	download.Close()
+	if !errors.Is(err, client.ErrDigestMismatch) {
+		t.Errorf("expected %v, got %v", client.ErrDigestMismatch, err)
+	}
+}
+
+func TestPostIsRetriedWithTheSameIdempotencyKey(t *testing.T) {
+	userService := &fakeUserService{createFails: 1}
+	server, keys := newServer(t, userService)
+	c := newClient(t, server)
+
+	user, err := c.CreateUser(context.Background(), &client.CreateUserRequest{Email: "me@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.UserId != "new-user" {
+		t.Errorf("expected the created user, got %+v", user)
+	}
+	if userService.createCalls != 2 {
+		t.Errorf("expected 2 calls, got %d", userService.createCalls)
+	}
+	if len(*keys) != 2 || (*keys)[0] == "" || (*keys)[0] != (*keys)[1] {
+		t.Errorf("expected both attempts to send the same idempotency key, got %q", *keys)
+	}
+}
+
+func TestCodesMatchTheService(t *testing.T) {
+	codes := map[string]string{
+		client.CodeValidationFailed:    apperrors.CodeValidationFailed,
+		client.CodeActorRequired:       apperrors.CodeActorRequired,
+		client.CodeIdempotencyKeyInUse: apperrors.CodeIdempotencyKeyInUse,
+		client.CodeUserNotFound:        apperrors.CodeUserNotFound,
+		client.CodeFileNotFound:        apperrors.CodeFileNotFound,
+		client.CodeFileDeleted:         apperrors.CodeFileDeleted,
+		client.CodeFileQuarantined:     apperrors.CodeFileQuarantined,
+		client.CodeFileTooLarge:        apperrors.CodeFileTooLarge,
+		client.CodeQuotaExceeded:       apperrors.CodeQuotaExceeded,
+		client.CodeVersionNotFound:     apperrors.CodeVersionNotFound,
+		client.CodeShareNotFound:       apperrors.CodeShareNotFound,
+		client.CodeJobNotFound:         apperrors.CodeJobNotFound,
+	}
+	for clientCode, serviceCode := range codes {
+		if clientCode != serviceCode {
+			t.Errorf("client code %s differs from service code %s", clientCode, serviceCode)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrDigestMismatch is returned by the reader of a download whose content does not
+// match the digest sent by the service.
+var ErrDigestMismatch = errors.New("users api: downloaded content does not match its digest")
+
+// Download is the content of a downloaded file, streamed from the response. It must
+// be closed.
+type Download struct {
+	io.ReadCloser
+	Filename    string
+	ContentType string
+	// Size is the length of the content, -1 when unknown.
+	Size int64
+	// Sha256 is the hex encoded digest of the content, empty when the service does
+	// not send one. The content is checked against it as it is read.
+	Sha256 string
+}
+
+// download sends a request whose response is streamed rather than decoded.
+func (c *Client) download(ctx context.Context, path string, query url.Values) (*Download, error) {
+	resp, err := c.send(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	download := &Download{
+		ReadCloser:  resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		download.Filename = params["filename"]
+	}
+	if digest := resp.Header.Get("Digest"); strings.HasPrefix(digest, "sha-256=") {
+		expected, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(digest, "sha-256="))
+		if err == nil {
+			download.Sha256 = hex.EncodeToString(expected)
+			download.ReadCloser = &digestReader{ReadCloser: resp.Body, hash: sha256.New(), expected: expected}
+		}
+	}
+	return download, nil
+}
+
+// digestReader hashes the content it reads, and fails with ErrDigestMismatch instead
+// of io.EOF when the content does not match the expected digest.
+type digestReader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected []byte
+}
+
+func (r *digestReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && !bytes.Equal(r.hash.Sum(nil), r.expected) {
+		return n, ErrDigestMismatch
+	}
+	return n, err
+}
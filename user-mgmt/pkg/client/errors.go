@@ -0,0 +1,111 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Stable codes of the errors most often handled by clients, see Error.Code. They
+// are kept in sync with the codes of the service by hand, so that the client does
+// not depend on its internal packages.
+const (
+	CodeValidationFailed    = "validation_failed"
+	CodeActorRequired       = "actor_required"
+	CodeIdempotencyKeyInUse = "idempotency_key_in_use"
+	CodeUserNotFound        = "user_not_found"
+	CodeFileNotFound        = "file_not_found"
+	CodeFileDeleted         = "file_deleted"
+	CodeFileQuarantined     = "file_quarantined"
+	CodeFileTooLarge        = "file_too_large"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeVersionNotFound     = "version_not_found"
+	CodeShareNotFound       = "share_not_found"
+	CodeJobNotFound         = "job_not_found"
+)
+
+// problemContentType is the media type of error responses, see RFC 7807.
+const problemContentType = "application/problem+json"
+
+// maxErrorBodySize limits how much of a response that is not a problem is kept as
+// the detail of its error.
+const maxErrorBodySize = 4 << 10
+
+// Error is a failed response of the API, decoded from its problem details.
+type Error struct {
+	// StatusCode is the status code of the response.
+	StatusCode int `json:"status"`
+	// Code is stable and identifies the error, empty when the response was not a problem.
+	Code string `json:"code"`
+	// Title is the text of the status code.
+	Title string `json:"title"`
+	// Detail is a human readable explanation of the error.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request.
+	Instance string `json:"instance,omitempty"`
+	// RequestId identifies the request in the logs of the service.
+	RequestId string `json:"request_id,omitempty"`
+	// Fields lists the invalid fields of validation errors.
+	Fields []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a field of a request that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("users api: %d %s", e.StatusCode, e.Title)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// ErrorCode returns the code of err if it is an *Error, or an empty string.
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// IsNotFound reports whether err is an *Error for a resource that does not exist.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// decodeError reads the problem details of a failed response. Responses of proxies
+// or other servers in front of the service are reported with their body as detail.
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return apiErr
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == problemContentType || mediaType == "application/json" {
+		if json.Unmarshal(body, apiErr) == nil && apiErr.StatusCode != 0 {
+			return apiErr
+		}
+		apiErr.StatusCode = resp.StatusCode
+	}
+	apiErr.Detail = strings.TrimSpace(string(body))
+	return apiErr
+}
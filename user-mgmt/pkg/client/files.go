@@ -0,0 +1,213 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func userFilesPath(userId string) string {
+	return userPath(userId) + "/files"
+}
+
+func userFilePath(userId, fileId string) string {
+	return userFilesPath(userId) + "/" + url.PathEscape(fileId)
+}
+
+func userFileVersionPath(userId, fileId string, version int) string {
+	return userFilePath(userId, fileId) + "/versions/" + strconv.Itoa(version)
+}
+
+// CreateUserFile creates a new user file, fetched from the file serving service or
+// imported from the url of request, once it is validated and stored.
+func (c *Client) CreateUserFile(ctx context.Context, userId string, request *UserFileRequest) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodPost, userFilesPath(userId), nil, request, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// CreateUserFileAsync queues the creation of a new user file, and returns the job
+// to poll with GetJob until it finishes.
+func (c *Client) CreateUserFileAsync(ctx context.Context, userId string, request *UserFileRequest) (*Job, error) {
+	job := &Job{}
+	query := url.Values{"async": {"true"}}
+	if err := c.call(ctx, http.MethodPost, userFilesPath(userId), query, request, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListUserFiles retrieves a page of the user files matching filter, which may be nil.
+func (c *Client) ListUserFiles(ctx context.Context, userId string, filter *UserFileFilter, opts *ListOptions) (*Page[UserFile], error) {
+	return listPage[UserFile](ctx, c, userFilesPath(userId), "user_files", filterQuery(filter), opts)
+}
+
+// UserFiles iterates over all user files matching filter, starting at the page of opts.
+func (c *Client) UserFiles(ctx context.Context, userId string, filter *UserFileFilter, opts *ListOptions) *Iterator[UserFile] {
+	return newIterator(ctx, opts, func(ctx context.Context, opts *ListOptions) (*Page[UserFile], error) {
+		return c.ListUserFiles(ctx, userId, filter, opts)
+	})
+}
+
+// GetUserFile retrieves a user file by id.
+func (c *Client) GetUserFile(ctx context.Context, userId string, fileId string) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodGet, userFilePath(userId, fileId), nil, nil, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// UpdateUserFile changes the filename, description, tags and expiry of a user file.
+func (c *Client) UpdateUserFile(ctx context.Context, userId string, fileId string, request *UserFileUpdateRequest) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodPatch, userFilePath(userId, fileId), nil, request, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// DeleteUserFile soft deletes a user file, it can be restored with RestoreUserFile.
+func (c *Client) DeleteUserFile(ctx context.Context, userId string, fileId string) error {
+	return c.call(ctx, http.MethodDelete, userFilePath(userId, fileId), nil, nil, nil)
+}
+
+// RestoreUserFile restores a soft deleted user file.
+func (c *Client) RestoreUserFile(ctx context.Context, userId string, fileId string) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodPost, userFilePath(userId, fileId)+":restore", nil, nil, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// PurgeUserFile permanently deletes a user file with its versions.
+func (c *Client) PurgeUserFile(ctx context.Context, userId string, fileId string) error {
+	return c.call(ctx, http.MethodPost, userFilePath(userId, fileId)+":purge", nil, nil, nil)
+}
+
+// DownloadUserFile streams the content of the current version of a user file.
+func (c *Client) DownloadUserFile(ctx context.Context, userId string, fileId string) (*Download, error) {
+	return c.download(ctx, userFilePath(userId, fileId)+"/download", nil)
+}
+
+// GetUserFileThumbnail streams a PNG preview of an image or PDF user file that fits
+// in a size x size box, the configured default size when size is 0.
+func (c *Client) GetUserFileThumbnail(ctx context.Context, userId string, fileId string, size int) (*Download, error) {
+	var query url.Values
+	if size > 0 {
+		query = url.Values{"size": {strconv.Itoa(size)}}
+	}
+	return c.download(ctx, userFilePath(userId, fileId)+"/thumbnail", query)
+}
+
+// ArchiveUserFiles streams a ZIP archive of the user files matching filter, which
+// may be nil, with a manifest of their metadata.
+func (c *Client) ArchiveUserFiles(ctx context.Context, userId string, filter *UserFileFilter) (*Download, error) {
+	return c.download(ctx, userFilesPath(userId)+":archive", filterQuery(filter))
+}
+
+// CreateUserFileVersion uploads a new version of a user file, fetched like
+// CreateUserFile, and makes it the current one.
+func (c *Client) CreateUserFileVersion(ctx context.Context, userId string, fileId string, request *UserFileRequest) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodPost, userFilePath(userId, fileId)+"/versions", nil, request, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// ListUserFileVersions retrieves a page of the versions of a user file, newest first.
+func (c *Client) ListUserFileVersions(ctx context.Context, userId string, fileId string, opts *ListOptions) (*Page[UserFileVersion], error) {
+	return listPage[UserFileVersion](ctx, c, userFilePath(userId, fileId)+"/versions", "versions", nil, opts)
+}
+
+// UserFileVersions iterates over all versions of a user file, starting at the page of opts.
+func (c *Client) UserFileVersions(ctx context.Context, userId string, fileId string, opts *ListOptions) *Iterator[UserFileVersion] {
+	return newIterator(ctx, opts, func(ctx context.Context, opts *ListOptions) (*Page[UserFileVersion], error) {
+		return c.ListUserFileVersions(ctx, userId, fileId, opts)
+	})
+}
+
+// DownloadUserFileVersion streams the content of a version of a user file.
+func (c *Client) DownloadUserFileVersion(ctx context.Context, userId string, fileId string, version int) (*Download, error) {
+	return c.download(ctx, userFileVersionPath(userId, fileId, version)+"/download", nil)
+}
+
+// RestoreUserFileVersion makes an older version of a user file the current one, by
+// storing a copy of it as a new version.
+func (c *Client) RestoreUserFileVersion(ctx context.Context, userId string, fileId string, version int) (*UserFile, error) {
+	userFile := &UserFile{}
+	if err := c.call(ctx, http.MethodPost, userFileVersionPath(userId, fileId, version)+":restore", nil, nil, userFile); err != nil {
+		return nil, err
+	}
+	return userFile, nil
+}
+
+// ShareUserFile creates an expiring link to the content of a user file, request may
+// be nil for the defaults.
+func (c *Client) ShareUserFile(ctx context.Context, userId string, fileId string, request *ShareRequest) (*Share, error) {
+	if request == nil {
+		request = &ShareRequest{}
+	}
+	share := &Share{}
+	if err := c.call(ctx, http.MethodPost, userFilePath(userId, fileId)+":share", nil, request, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// ListUserFileShares retrieves a page of the shares of a user file.
+func (c *Client) ListUserFileShares(ctx context.Context, userId string, fileId string, opts *ListOptions) (*Page[Share], error) {
+	return listPage[Share](ctx, c, userFilePath(userId, fileId)+"/shares", "shares", nil, opts)
+}
+
+// UserFileShares iterates over all shares of a user file, starting at the page of opts.
+func (c *Client) UserFileShares(ctx context.Context, userId string, fileId string, opts *ListOptions) *Iterator[Share] {
+	return newIterator(ctx, opts, func(ctx context.Context, opts *ListOptions) (*Page[Share], error) {
+		return c.ListUserFileShares(ctx, userId, fileId, opts)
+	})
+}
+
+// RevokeUserFileShare revokes a share of a user file, its link stops working.
+func (c *Client) RevokeUserFileShare(ctx context.Context, userId string, fileId string, shareId string) (*Share, error) {
+	share := &Share{}
+	path := userFilePath(userId, fileId) + "/shares/" + url.PathEscape(shareId)
+	if err := c.call(ctx, http.MethodDelete, path, nil, nil, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// filterQuery encodes filter as the query string of file lists.
+func filterQuery(filter *UserFileFilter) url.Values {
+	query := url.Values{}
+	if filter == nil {
+		return query
+	}
+	if filter.FileType != "" {
+		query.Set("file_type", filter.FileType)
+	}
+	for _, tag := range filter.Tags {
+		query.Add("tag", tag)
+	}
+	if filter.MinPages != nil {
+		query.Set("min_pages", strconv.Itoa(*filter.MinPages))
+	}
+	if filter.MaxPages != nil {
+		query.Set("max_pages", strconv.Itoa(*filter.MaxPages))
+	}
+	if filter.Title != "" {
+		query.Set("title", filter.Title)
+	}
+	if filter.Author != "" {
+		query.Set("author", filter.Author)
+	}
+	if filter.Encrypted != nil {
+		query.Set("encrypted", strconv.FormatBool(*filter.Encrypted))
+	}
+	return query
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func jobPath(jobId string) string {
+	return "/v1/jobs/" + url.PathEscape(jobId)
+}
+
+// GetJob retrieves the status, progress, result and error of a background job.
+func (c *Client) GetJob(ctx context.Context, jobId string) (*Job, error) {
+	job := &Job{}
+	if err := c.call(ctx, http.MethodGet, jobPath(jobId), nil, nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CancelJob cancels a background job. Queued jobs are cancelled right away, running
+// jobs stop at their next checkpoint.
+func (c *Client) CancelJob(ctx context.Context, jobId string) (*Job, error) {
+	job := &Job{}
+	if err := c.call(ctx, http.MethodPost, jobPath(jobId)+":cancel", nil, nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
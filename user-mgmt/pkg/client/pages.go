@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Page is a page of a list.
+type Page[T any] struct {
+	Items       []T
+	CurrentPage int
+	TotalRows   int64
+	TotalPages  int
+}
+
+// pageResponse is the pagination envelope of lists, whose data holds the items
+// under a key named after the list.
+type pageResponse[T any] struct {
+	CurrentPage int            `json:"current_page"`
+	TotalRows   int64          `json:"total_rows"`
+	TotalPages  int            `json:"total_pages"`
+	Data        map[string][]T `json:"data"`
+}
+
+func listPage[T any](ctx context.Context, c *Client, path, key string, query url.Values, opts *ListOptions) (*Page[T], error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if opts != nil {
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Page > 0 {
+			query.Set("page", strconv.Itoa(opts.Page))
+		}
+		if opts.Sort != "" {
+			query.Set("sort", opts.Sort)
+		}
+	}
+
+	resp := &pageResponse[T]{}
+	if err := c.call(ctx, http.MethodGet, path, query, nil, resp); err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{
+		Items:       resp.Data[key],
+		CurrentPage: resp.CurrentPage,
+		TotalRows:   resp.TotalRows,
+		TotalPages:  resp.TotalPages,
+	}
+	if page.CurrentPage == 0 {
+		page.CurrentPage = 1
+		if opts != nil && opts.Page > 0 {
+			page.CurrentPage = opts.Page
+		}
+	}
+	return page, nil
+}
+
+// Iterator goes through the items of a list, fetching its pages as they are needed:
+//
+//	it := c.Users(ctx, nil)
+//	for it.Next() {
+//		user := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, opts *ListOptions) (*Page[T], error)
+	opts  ListOptions
+	page  *Page[T]
+	index int
+	item  T
+	done  bool
+	err   error
+}
+
+func newIterator[T any](ctx context.Context, opts *ListOptions, fetch func(ctx context.Context, opts *ListOptions) (*Page[T], error)) *Iterator[T] {
+	it := &Iterator[T]{ctx: ctx, fetch: fetch}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if it.opts.Page < 1 {
+		it.opts.Page = 1
+	}
+	return it
+}
+
+// Next advances to the next item, and reports whether there is one. It returns false
+// at the end of the list or when a page could not be fetched, see Err.
+func (it *Iterator[T]) Next() bool {
+	for {
+		if it.page != nil && it.index < len(it.page.Items) {
+			it.item = it.page.Items[it.index]
+			it.index++
+			return true
+		}
+		if it.done || it.err != nil {
+			return false
+		}
+
+		page, err := it.fetch(it.ctx, &it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.index = page, 0
+		it.done = len(page.Items) == 0 || page.CurrentPage >= page.TotalPages
+		it.opts.Page = page.CurrentPage + 1
+	}
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
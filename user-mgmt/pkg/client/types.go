@@ -0,0 +1,158 @@
+package client
+
+import (
+	"time"
+)
+
+// request
+
+type CreateUserRequest struct {
+	Email string `json:"email"`
+}
+
+type verifyUserRequest struct {
+	Token string `json:"token"`
+}
+
+// UserFileRequest creates a user file, fetched from the file serving service unless
+// Url is set.
+type UserFileRequest struct {
+	// Url is the http(s) url the file is imported from, when the service allows imports.
+	Url         string     `json:"url,omitempty"`
+	Filename    string     `json:"filename,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// UserFileUpdateRequest changes the fields of a user file that are set, the others
+// are kept.
+type UserFileUpdateRequest struct {
+	Filename    *string    `json:"filename,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Tags        *[]string  `json:"tags,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// NoExpiry removes the expiry of the file, it cannot be set with ExpiresAt.
+	NoExpiry bool `json:"no_expiry,omitempty"`
+}
+
+// UserFileFilter selects user files, its zero value matches all of them.
+type UserFileFilter struct {
+	FileType string
+	// Tags the files must all have.
+	Tags      []string
+	MinPages  *int
+	MaxPages  *int
+	Title     string
+	Author    string
+	Encrypted *bool
+}
+
+type ShareRequest struct {
+	// ExpiresIn is the lifetime of the link in seconds, the configured default when omitted.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+	// MaxDownloads limits how many times the link can be used, unlimited when omitted.
+	MaxDownloads *int `json:"max_downloads,omitempty"`
+}
+
+// ListOptions selects a page of a list, the defaults of the service are used for
+// zero values.
+type ListOptions struct {
+	Limit int
+	Page  int
+	// Sort orders the list by a field, e.g. created_at.desc.
+	Sort string
+}
+
+// response
+
+type User struct {
+	UserId          string     `json:"user_id"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+type UserUsage struct {
+	UserId      string `json:"user_id"`
+	FileCount   int64  `json:"file_count"`
+	MaxFiles    int64  `json:"max_files"`
+	UsedBytes   int64  `json:"used_bytes"`
+	MaxBytes    int64  `json:"max_bytes"`
+	MaxFileSize int64  `json:"max_file_size"`
+}
+
+type UserFile struct {
+	FileId      string   `json:"file_id"`
+	Filename    *string  `json:"filename,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags"`
+	FileType    string   `json:"file_type"`
+	FileSize    int64    `json:"file_size"`
+	Sha256      string   `json:"sha256"`
+	Version     int      `json:"version"`
+	// Status is available, or quarantined when the file failed its malware scan.
+	Status           string            `json:"status"`
+	QuarantineReason *string           `json:"quarantine_reason,omitempty"`
+	Metadata         *UserFileMetadata `json:"metadata,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// UserFileMetadata is extracted from PDF documents.
+type UserFileMetadata struct {
+	PageCount  int        `json:"page_count"`
+	Title      string     `json:"title,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	Encrypted  bool       `json:"encrypted"`
+	PdfVersion string     `json:"pdf_version,omitempty"`
+}
+
+type UserFileVersion struct {
+	FileId           string    `json:"file_id"`
+	Version          int       `json:"version"`
+	Current          bool      `json:"current"`
+	FileType         string    `json:"file_type"`
+	FileSize         int64     `json:"file_size"`
+	Sha256           string    `json:"sha256"`
+	Status           string    `json:"status"`
+	QuarantineReason *string   `json:"quarantine_reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type Share struct {
+	ShareId string `json:"share_id"`
+	FileId  string `json:"file_id"`
+	// Url is only returned when the share is created, it cannot be retrieved later.
+	Url           string     `json:"url,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	MaxDownloads  *int       `json:"max_downloads,omitempty"`
+	DownloadCount int        `json:"download_count"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type Job struct {
+	JobId     string  `json:"job_id"`
+	Type      string  `json:"type"`
+	UserId    string  `json:"user_id"`
+	SourceUrl *string `json:"source_url,omitempty"`
+	// Status is one of queued, running, succeeded, failed or cancelled.
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Attempts int    `json:"attempts"`
+	// FileId is the file created by a succeeded job.
+	FileId *string `json:"file_id,omitempty"`
+	Error  *string `json:"error,omitempty"`
+	// ErrorCode is the status code of the error of a failed job, as if it had been
+	// returned by a request.
+	ErrorCode *int `json:"error_code,omitempty"`
+	// Code is the stable code of the error of a failed job, see Error.Code.
+	Code            *string    `json:"code,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const usersPath = "/v1/users"
+
+func userPath(userId string) string {
+	return usersPath + "/" + url.PathEscape(userId)
+}
+
+// CreateUser creates a new user.
+func (c *Client) CreateUser(ctx context.Context, request *CreateUserRequest) (*User, error) {
+	user := &User{}
+	if err := c.call(ctx, http.MethodPost, usersPath, nil, request, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListUsers retrieves a page of users.
+func (c *Client) ListUsers(ctx context.Context, opts *ListOptions) (*Page[User], error) {
+	return listPage[User](ctx, c, usersPath, "users", nil, opts)
+}
+
+// Users iterates over all users, starting at the page of opts.
+func (c *Client) Users(ctx context.Context, opts *ListOptions) *Iterator[User] {
+	return newIterator(ctx, opts, c.ListUsers)
+}
+
+// GetUser retrieves a user by id.
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
+	user := &User{}
+	if err := c.call(ctx, http.MethodGet, userPath(userId), nil, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser soft deletes a user by id, it can be restored with RestoreUser.
+func (c *Client) DeleteUser(ctx context.Context, userId string) error {
+	return c.call(ctx, http.MethodDelete, userPath(userId), nil, nil, nil)
+}
+
+// RestoreUser restores a soft deleted user by id.
+func (c *Client) RestoreUser(ctx context.Context, userId string) (*User, error) {
+	user := &User{}
+	if err := c.call(ctx, http.MethodPost, userPath(userId)+":restore", nil, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// VerifyUser marks the email of a user as verified with the token sent to it.
+func (c *Client) VerifyUser(ctx context.Context, userId string, token string) (*User, error) {
+	user := &User{}
+	if err := c.call(ctx, http.MethodPost, userPath(userId)+":verify", nil, &verifyUserRequest{Token: token}, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserUsage retrieves the storage used by a user and its quotas.
+func (c *Client) GetUserUsage(ctx context.Context, userId string) (*UserUsage, error) {
+	usage := &UserUsage{}
+	if err := c.call(ctx, http.MethodGet, userPath(userId)+"/usage", nil, nil, usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
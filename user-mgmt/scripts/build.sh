@@ -9,4 +9,5 @@ export GOARCH=amd64
 export CGO_ENABLED=0
 
 go mod tidy
-go build -a -o bin/users-api cmd/main.go
\ No newline at end of file
+go build -a -o bin/users-api cmd/main.go
+go build -a -o bin/rotate-keys ./cmd/rotate-keys
\ No newline at end of file